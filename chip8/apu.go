@@ -1,12 +1,223 @@
 package CHIP8
 
-import "fmt"
+import (
+	"math"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const (
+	sampleRate    = 44100           // Samples per second of generated audio
+	toneFrequency = 440             // Default pitch of the beep in Hz
+	audioLatency  = sampleRate / 20 // Samples kept queued while the beep plays, 50ms or 3 frames
+)
+
+// Sound is what the run loop plays the beep on. The APU is the default; NullSound is silent.
+type Sound interface {
+	// On starts the beep when the sound timer starts running.
+	On()
+
+	// Off stops the beep when the sound timer reaches 0.
+	Off()
+}
+
+var _ Sound = (*APU)(nil)
 
 type APU struct {
+	volume    float64 // Amplitude of the tone, from 0.0 (silent) to 1.0 (full scale)
+	frequency float64 // Pitch of the tone in Hz
+	muted     bool
+	phase     float64 // Position within the current period of the square wave, from 0.0 to 1.0
+
+	pattern *[16]byte // XO-CHIP audio pattern played instead of the square wave, or nil
+	rate    float64   // Bits of the pattern played per second
+
+	device  sdl.AudioDeviceID // Audio device the tone is queued on, or 0 if it couldn't be opened
+	playing bool              // Whether the beep is on
+	buf     []int8            // Samples being generated
+	queue   []byte            // The same samples as bytes for SDL
+}
+
+// Init opens an SDL audio device for signed 8-bit mono samples. SDL's audio subsystem must
+// already be initialized, which PPU.Init does. If the device can't be opened, the error is
+// returned and the APU stays silent, but can still generate samples.
+func (apu *APU) Init() error {
+	apu.volume = 1.0
+	apu.frequency = toneFrequency
+
+	spec := sdl.AudioSpec{
+		Freq:     sampleRate,
+		Format:   sdl.AUDIO_S8,
+		Channels: 1,
+		Samples:  512,
+	}
+
+	device, err := sdl.OpenAudioDevice("", false, &spec, nil, 0)
+	if err != nil {
+		return err
+	}
+
+	apu.device = device
+
+	return nil
+}
+
+// Close closes the audio device. It must be called before SDL is shut down.
+func (apu *APU) Close() {
+	if apu.device == 0 {
+		return
+	}
+
+	sdl.CloseAudioDevice(apu.device)
+	apu.device = 0
+}
+
+// SetFrequency sets the pitch of the tone in Hz. Frequencies the sample rate can't represent are
+// clamped to the Nyquist limit, and anything not above 0 is ignored. The phase is kept, so
+// changing pitch mid-tone doesn't click.
+func (apu *APU) SetFrequency(hz float64) {
+	if hz <= 0 {
+		return
+	}
+
+	if hz > sampleRate/2 {
+		hz = sampleRate / 2
+	}
+
+	apu.frequency = hz
+}
+
+// SetMuted silences the APU without affecting the sound timer.
+func (apu *APU) SetMuted(muted bool) {
+	apu.muted = muted
+}
+
+// SetVolume scales the amplitude of the tone. Values outside 0.0 - 1.0 are clamped.
+func (apu *APU) SetVolume(volume float64) {
+	if volume < 0 {
+		volume = 0
+	} else if volume > 1 {
+		volume = 1
+	}
+
+	apu.volume = volume
+}
+
+// SetPattern plays the 128 bits of an XO-CHIP audio pattern, most significant bit first, instead of
+// the square wave. pitch sets the playback rate to 4000 * 2^((pitch - 64) / 48) bits per second.
+func (apu *APU) SetPattern(pattern *[16]byte, pitch byte) {
+	apu.pattern = pattern
+	apu.rate = 4000 * math.Pow(2, (float64(pitch)-64)/48)
 }
 
-func (apu *APU) beep() {
-	// Simple audio output that uses the system's alert sound to emulate a Chip-8 beep
-	fmt.Print("\x07")
+// generate fills buf with the next samples of a square wave tone as signed 8-bit PCM.
+// The phase carries over between calls so consecutive buffers join without a click.
+func (apu *APU) generate(buf []int8) {
+	amplitude := int8(0)
+	if !apu.muted {
+		amplitude = int8(apu.volume * 127)
+	}
+
+	if apu.pattern != nil {
+		apu.generatePattern(buf, amplitude)
+		return
+	}
+
+	step := apu.frequency / sampleRate
+
+	for i := range buf {
+		if apu.phase < 0.5 {
+			buf[i] = amplitude
+		} else {
+			buf[i] = -amplitude
+		}
+
+		if apu.phase += step; apu.phase >= 1 {
+			apu.phase -= 1
+		}
+	}
+}
+
+// generatePattern fills buf from the audio pattern, with phase running over all 128 bits.
+func (apu *APU) generatePattern(buf []int8, amplitude int8) {
+	step := apu.rate / sampleRate / 128
+
+	for i := range buf {
+		bit := int(apu.phase * 128)
+
+		if apu.pattern[bit/8]&(0x80>>uint(bit%8)) != 0 {
+			buf[i] = amplitude
+		} else {
+			buf[i] = -amplitude
+		}
+
+		if apu.phase += step; apu.phase >= 1 {
+			apu.phase -= 1
+		}
+	}
+}
+
+// On starts playing the tone.
+func (apu *APU) On() {
+	apu.playing = true
+
+	if apu.device == 0 {
+		return
+	}
+
+	apu.update()
+	sdl.PauseAudioDevice(apu.device, false)
+}
+
+// Off stops the tone, dropping whatever is still queued so it stops right away.
+func (apu *APU) Off() {
+	apu.playing = false
+
+	if apu.device == 0 {
+		return
+	}
+
+	sdl.PauseAudioDevice(apu.device, true)
+	sdl.ClearQueuedAudio(apu.device)
+}
+
+// update tops up the audio queue while the tone plays. It is called once per frame, and the
+// queue holds a few frames of audio, so the tone doesn't break up when a frame runs late.
+func (apu *APU) update() {
+	if apu.device == 0 || !apu.playing {
+		return
+	}
+
+	if samples := apu.samples(sdl.GetQueuedAudioSize(apu.device)); len(samples) > 0 {
+		sdl.QueueAudio(apu.device, samples)
+	}
+}
+
+// samples generates what's needed to bring a queue of queued samples up to audioLatency.
+func (apu *APU) samples(queued uint32) []byte {
+	if queued >= audioLatency {
+		return nil
+	}
+
+	n := audioLatency - int(queued)
+	if cap(apu.buf) < n {
+		apu.buf = make([]int8, n)
+		apu.queue = make([]byte, n)
+	}
+
+	buf, queue := apu.buf[:n], apu.queue[:n]
+	apu.generate(buf)
+
+	for i, sample := range buf {
+		queue[i] = byte(sample)
+	}
+
+	return queue
 }
 
+// NullSound is a Sound that plays nothing.
+type NullSound struct{}
+
+func (NullSound) On() {}
+
+func (NullSound) Off() {}
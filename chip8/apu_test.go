@@ -0,0 +1,217 @@
+package CHIP8
+
+import (
+	"testing"
+)
+
+// peak returns the largest absolute sample in buf.
+func peak(buf []int8) int {
+	max := 0
+	for _, sample := range buf {
+		s := int(sample)
+		if s < 0 {
+			s = -s
+		}
+		if s > max {
+			max = s
+		}
+	}
+
+	return max
+}
+
+func TestAPUVolume(t *testing.T) {
+	apu := &APU{}
+	apu.Init()
+	buf := make([]int8, sampleRate/toneFrequency*2)
+
+	if apu.generate(buf); peak(buf) != 127 {
+		t.Errorf("TestAPUVolume: unexpected amplitude at full volume. Expected: %d Received: %d", 127, peak(buf))
+	}
+
+	apu.SetVolume(0.5)
+	if apu.generate(buf); peak(buf) != 63 {
+		t.Errorf("TestAPUVolume: unexpected amplitude at half volume. Expected: %d Received: %d", 63, peak(buf))
+	}
+
+	apu.SetVolume(7)
+	if apu.generate(buf); peak(buf) != 127 {
+		t.Errorf("TestAPUVolume: failed to clamp volume. Expected: %d Received: %d", 127, peak(buf))
+	}
+}
+
+func TestAPUMute(t *testing.T) {
+	apu := &APU{}
+	apu.Init()
+	buf := make([]int8, sampleRate/toneFrequency*2)
+
+	apu.SetMuted(true)
+	if apu.generate(buf); peak(buf) != 0 {
+		t.Errorf("TestAPUMute: generated sound while muted. Expected: %d Received: %d", 0, peak(buf))
+	}
+
+	apu.SetMuted(false)
+	if apu.generate(buf); peak(buf) != 127 {
+		t.Errorf("TestAPUMute: failed to unmute. Expected: %d Received: %d", 127, peak(buf))
+	}
+}
+
+// risingEdges returns the indices of the samples where buf goes from low to high.
+func risingEdges(buf []int8) []int {
+	var edges []int
+	for i := 1; i < len(buf); i++ {
+		if buf[i-1] < 0 && buf[i] > 0 {
+			edges = append(edges, i)
+		}
+	}
+
+	return edges
+}
+
+func TestAPUFrequency(t *testing.T) {
+	apu := &APU{}
+	apu.Init()
+	buf := make([]int8, sampleRate)
+
+	for _, hz := range []float64{440, 1000, 150} {
+		apu.SetFrequency(hz)
+		apu.generate(buf)
+
+		edges := risingEdges(buf)
+		if len(edges) < 2 {
+			t.Fatalf("TestAPUFrequency: no periods generated at %vHz", hz)
+		}
+
+		// Average period across the whole second. Each edge is rounded to a sample, which barely moves it
+		period := float64(edges[len(edges)-1]-edges[0]) / float64(len(edges)-1)
+		expected := sampleRate / hz
+
+		if diff := period - expected; diff > 0.1 || diff < -0.1 {
+			t.Errorf("TestAPUFrequency: unexpected period at %vHz. Expected: %.2f Received: %.2f", hz, expected, period)
+		}
+	}
+
+	apu.SetFrequency(-5)
+	if apu.frequency != 150 {
+		t.Errorf("TestAPUFrequency: failed to ignore an invalid frequency. Expected: %v Received: %v", 150.0, apu.frequency)
+	}
+}
+
+func TestAPUSquareWave(t *testing.T) {
+	apu := &APU{}
+	apu.Init()
+	apu.SetFrequency(441) // A whole number of samples, 100, per period
+
+	samples := apu.samples(0)
+	if len(samples) != audioLatency {
+		t.Fatalf("TestAPUSquareWave: unexpected number of samples for an empty queue. Expected: %d Received: %d", audioLatency, len(samples))
+	}
+
+	// Each period is 50 samples high followed by 50 low
+	for i, sample := range samples {
+		expected := int8(127)
+		if i%100 >= 50 {
+			expected = -127
+		}
+
+		if int8(sample) != expected {
+			t.Fatalf("TestAPUSquareWave: sample %d isn't part of a square wave. Expected: %d Received: %d", i, expected, int8(sample))
+		}
+	}
+
+	if samples := apu.samples(audioLatency - 10); len(samples) != 10 {
+		t.Errorf("TestAPUSquareWave: failed to top up the queue. Expected: %d Received: %d", 10, len(samples))
+	}
+
+	if samples := apu.samples(audioLatency); len(samples) != 0 {
+		t.Errorf("TestAPUSquareWave: queued samples while the queue was full. Received: %d", len(samples))
+	}
+}
+
+func TestAPUPattern(t *testing.T) {
+	cpu := &CPU{}
+	cpu.Init()
+	cpu.PC = 0x200
+	copy(cpu.RAM[0x300:], []byte{0xF0, 0x0F, 0xAA, 0x55, 0xFF, 0x00, 0x81, 0x18, 0xCC, 0x33, 0xC3, 0x3C, 0xE7, 0x7E, 0x01, 0x80})
+	copy(cpu.RAM[0x200:], []byte{
+		0xA3, 0x00, // 200: I = 0x300
+		0xF0, 0x02, // 202: load the audio pattern
+		0x60, 0x70, // 204: V0 = 112
+		0xF0, 0x3A, // 206: pitch = V0
+	})
+
+	for i := 0; i < 4; i++ {
+		if err := cpu.Cycle(); err != nil {
+			t.Fatalf("TestAPUPattern: cycle failed: %v", err)
+		}
+	}
+
+	if cpu.Pattern != [16]byte{0xF0, 0x0F, 0xAA, 0x55, 0xFF, 0x00, 0x81, 0x18, 0xCC, 0x33, 0xC3, 0x3C, 0xE7, 0x7E, 0x01, 0x80} || cpu.Pitch != 112 {
+		t.Fatalf("TestAPUPattern: failed to load the pattern and pitch. Pattern: %X Pitch: %d", cpu.Pattern, cpu.Pitch)
+	}
+
+	apu := &APU{}
+	apu.Init()
+	apu.SetPattern(&cpu.Pattern, cpu.Pitch)
+
+	// Pitch 112 plays 8000 bits per second, so 128 bits take 705.6 samples
+	if apu.rate != 8000 {
+		t.Fatalf("TestAPUPattern: unexpected playback rate. Expected: %v Received: %v", 8000.0, apu.rate)
+	}
+
+	buf := make([]int8, 705)
+	apu.generate(buf)
+
+	for i, sample := range buf {
+		bit := int(float64(i) * 8000 / sampleRate)
+
+		expected := int8(-127)
+		if cpu.Pattern[bit/8]&(0x80>>uint(bit%8)) != 0 {
+			expected = 127
+		}
+
+		if sample != expected {
+			t.Fatalf("TestAPUPattern: sample %d doesn't follow bit %d. Expected: %d Received: %d", i, bit, expected, sample)
+		}
+	}
+}
+
+// fakeSound records whether the beep is playing and how often it was started.
+type fakeSound struct {
+	playing bool
+	ons     int
+	offs    int
+}
+
+func (sound *fakeSound) On() {
+	sound.playing = true
+	sound.ons++
+}
+
+func (sound *fakeSound) Off() {
+	sound.playing = false
+	sound.offs++
+}
+
+func TestSound(t *testing.T) {
+	sound := &fakeSound{}
+	chip8 := newTestChip8(&fakeDisplay{})
+	chip8.SetSound(sound)
+	copy(chip8.cpu.RAM[0x200:], []byte{
+		0x60, 0x03, // 200: V0 = 3
+		0xF0, 0x18, // 202: ST = V0
+		0x12, 0x04, // 204: jump 204
+	})
+
+	for frame := 0; frame < 6; frame++ {
+		chip8.runFrame(3)
+
+		if sound.playing != (chip8.cpu.ST > 0) {
+			t.Errorf("TestSound: beep doesn't follow the sound timer on frame %d. ST: %d Playing: %t", frame, chip8.cpu.ST, sound.playing)
+		}
+	}
+
+	if sound.ons != 1 || sound.offs != 1 {
+		t.Errorf("TestSound: expected the beep to start and stop once. Received: %d starts, %d stops", sound.ons, sound.offs)
+	}
+}
@@ -0,0 +1,58 @@
+package CHIP8
+
+import (
+	"testing"
+)
+
+// A loop mixing arithmetic, branches, memory and drawing, roughly in the proportions games use.
+var benchROM = []byte{
+	0x60, 0x10, // 200: V0 = 10
+	0x61, 0x08, // 202: V1 = 8
+	0x70, 0x01, // 204: V0 += 1
+	0x80, 0x14, // 206: V0 += V1
+	0x82, 0x03, // 208: V2 ^= V0
+	0x30, 0x00, // 20A: skip if V0 == 0
+	0x83, 0x06, // 20C: V3 >>= 1
+	0xC4, 0x3F, // 20E: V4 = random & 3F
+	0xA3, 0x00, // 210: I = 300
+	0xF4, 0x33, // 212: BCD of V4 at I
+	0xF2, 0x65, // 214: load V0 - V2 from I
+	0xF4, 0x29, // 216: I = sprite for V4
+	0xD4, 0x05, // 218: draw at (V4, V0)
+	0x12, 0x04, // 21A: jump 204
+}
+
+func BenchmarkCycle(b *testing.B) {
+	cpu := &CPU{}
+	cpu.Init()
+	cpu.Seed(1)
+	cpu.PC = 0x200
+	copy(cpu.RAM[0x200:], benchROM)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := cpu.Cycle(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDraw(b *testing.B) {
+	cpu := &CPU{}
+	cpu.Init()
+	cpu.I = 0x300
+	copy(cpu.RAM[0x300:], []byte{0xFF, 0x81, 0xBD, 0xA5, 0xA5, 0xBD, 0x81, 0xFF})
+	cpu.V[0x0] = 60
+	cpu.V[0x1] = 28
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := cpu.draw(0x0, 0x1, 8); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
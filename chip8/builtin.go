@@ -0,0 +1,39 @@
+package CHIP8
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Small public domain ROMs bundled with the emulator, written for it:
+//
+//	counter: counts from 0 to 255 in decimal, ten times a second.
+//	digits:  shows each hex digit of the font for half a second.
+//	keypad:  shows the hex digit of each key pressed.
+//
+//go:embed roms/*.ch8
+var builtinROMs embed.FS
+
+// Builtins returns the names of the bundled ROMs in alphabetical order.
+func Builtins() []string {
+	entries, _ := builtinROMs.ReadDir("roms")
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".ch8"))
+	}
+
+	return names
+}
+
+// BuiltinROM returns the bytes of the bundled ROM called name.
+func BuiltinROM(name string) ([]byte, error) {
+	rom, err := builtinROMs.ReadFile(path.Join("roms", name+".ch8"))
+	if err != nil {
+		return nil, fmt.Errorf("no built-in ROM called %q, expected one of: %s", name, strings.Join(Builtins(), ", "))
+	}
+
+	return rom, nil
+}
@@ -0,0 +1,60 @@
+package CHIP8
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestBuiltins(t *testing.T) {
+	expected := []string{"counter", "digits", "keypad"}
+	if names := Builtins(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("TestBuiltins: unexpected built-in ROMs. Expected: %v Received: %v", expected, names)
+	}
+
+	rom, err := BuiltinROM("digits")
+	if err != nil {
+		t.Fatalf("TestBuiltins: failed to read a built-in ROM: %v", err)
+	}
+
+	chip8 := newTestChip8(&fakeDisplay{})
+	if err := chip8.LoadBuiltin("digits"); err != nil {
+		t.Fatalf("TestBuiltins: failed to load a built-in ROM: %v", err)
+	}
+
+	if len(rom) == 0 || !bytes.Equal(chip8.cpu.RAM[0x200:0x200+len(rom)], rom) {
+		t.Errorf("TestBuiltins: RAM doesn't hold the built-in ROM. Expected: %X Received: %X", rom, chip8.cpu.RAM[0x200:0x200+len(rom)])
+	}
+
+	if err := chip8.LoadBuiltin("pong"); err == nil {
+		t.Errorf("TestBuiltins: expected an error for an unknown built-in ROM")
+	}
+}
+
+func TestBuiltinsRun(t *testing.T) {
+	// Every built-in ROM runs without hitting an unknown instruction
+	for _, name := range Builtins() {
+		// Hold a key for the keypad ROM, which waits for one with Fx0A straight away
+		chip8 := newTestChip8(&fakeDisplay{poll: func(polls int, key *[16]bool) bool {
+			key[0x1] = true
+			return false
+		}})
+		if err := chip8.LoadBuiltin(name); err != nil {
+			t.Fatalf("TestBuiltinsRun: failed to load %s: %v", name, err)
+		}
+
+		blank := gfxString(&[32][64]byte{})
+		drew := false
+		chip8.OnDraw = func(gfx *[32][64]byte) {
+			drew = drew || gfxString(gfx) != blank
+		}
+
+		for frame := 0; frame < 120; frame++ {
+			chip8.runFrame(11)
+		}
+
+		if !drew {
+			t.Errorf("TestBuiltinsRun: %s didn't draw anything", name)
+		}
+	}
+}
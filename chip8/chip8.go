@@ -1,76 +1,707 @@
 package CHIP8
 
 import (
+	"context"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
 )
 
 type Chip8 struct {
 	cpu *CPU
-	ppu *PPU
-	apu *APU
+	ppu Display
+	apu Sound
+
+	shutdown sync.Once // Guards against destroying the display twice
+
+	stop      chan struct{} // Closed by Stop to end the run loop, see stopped
+	stopInit  sync.Once     // Guards creating stop
+	stopClose sync.Once     // Guards closing stop
+
+	snapshot     Snapshot     // State at the end of the last frame, see Snapshot
+	snapshotLock sync.RWMutex // Guards snapshot
+
+	frame      uint64        // Number of frames emulated so far
+	cycleLimit uint64        // Stop after this many instructions, or never if 0
+	redraw     bool          // Draw every frame, not just after the CPU sets the draw flag
+	vsync      bool          // Pace frames by the display's refresh instead of a ticker
+	frameTime  time.Duration // Real time the current frame represents, 1/60s if 0
+	timers     timerClock
+	present    presentClock // Limits presented frames to the fps passed to Run
+	quirksSet  bool         // Whether the quirks were chosen by the user rather than detected
+	meter      rateMeter
+	recorder   *InputRecorder
+	player     *InputPlayer
+	beeping    bool          // Whether the sound timer was running at the end of the last frame
+	rewind     *rewindBuffer // Recent frames to rewind to, or nil if rewinding is off
+	debugger   *Debugger     // Debugger the run loop steps through, or nil
+	paused     atomic.Bool   // Whether the run loop is paused by Pause or stopped at a breakpoint
+	speed      atomic.Int64  // Instructions per frame set by SetSpeed, overriding Run's ipf if not 0
+	crashLog   io.Writer     // Where the CPU state is dumped if the run loop panics, os.Stderr if nil
+	err        error         // Error that stopped the run loop, returned by RunContext
+	gif        *gifRecording // GIF being recorded, or nil
+
+	// OnCycle is called after each instruction with its address and opcode.
+	OnCycle func(pc uint16, opCode uint16)
+
+	// OnDraw is called after the screen is presented. The SUPER-CHIP high resolution screen is
+	// passed halved to 64x32.
+	OnDraw func(gfx *[32][64]byte)
+
+	// OnBeep is called when the sound starts and when it stops.
+	OnBeep func(on bool)
+
+	// OnBreak is called when the run loop stops at one of the debugger's breakpoints.
+	OnBreak func(hit *Break)
+}
+
+// Display is what the run loop draws frames on and reads the keys from. The PPU is the SDL
+// window; NullDisplay and ImageDisplay run without SDL.
+type Display interface {
+	// Draw presents a frame.
+	Draw(gfx *[32][64]byte)
+
+	// Poll updates key with the state of the keypad and reports whether to quit.
+	Poll(key *[16]bool) bool
+
+	// Destroy releases the display. Shutdown calls it once.
+	Destroy()
+}
+
+// regionDisplay is implemented by displays that can redraw just the part of the screen that changed.
+type regionDisplay interface {
+	DrawRegion(gfx *[32][64]byte, x int, y int, w int, h int)
+}
+
+// colorDisplay is implemented by displays with a palette.
+type colorDisplay interface {
+	SetColor(index int, c color.RGBA)
+}
+
+// rewindDisplay is implemented by displays with a control for stepping back through recent frames.
+type rewindDisplay interface {
+	// Rewinding reports whether to step back a frame rather than emulate the next one.
+	Rewinding() bool
+}
+
+// statsDisplay is implemented by displays that can show the measured FPS and IPS.
+type statsDisplay interface {
+	SetStats(fps float64, ips float64)
 }
 
-func (chip8 *Chip8) Init() {
+// Init sets up the CHIP-8 with an SDL window, returning an error if the window can't be opened.
+func (chip8 *Chip8) Init() error {
 	// Initialize CPU
 	chip8.cpu = &CPU{}
 	chip8.cpu.Init()
 
 	// Initialize PPU
-	chip8.ppu = &PPU{}
-	chip8.ppu.Init()
+	ppu := &PPU{}
+	if err := ppu.Init(); err != nil {
+		return err
+	}
+	chip8.ppu = ppu
+
+	// Initialize APU, without sound if there's no audio device
+	apu := &APU{}
+	if err := apu.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open audio device, sound is off: %v\n", err)
+	}
+	chip8.apu = apu
 
-	// Initialize APU
-	chip8.apu = &APU{}
+	return nil
 }
 
 func (chip8 *Chip8) Load(filename *string) error {
-	if err := chip8.cpu.LoadROM(filename); err != nil {
+	// Fetch ROMs given as a URL
+	var err error
+	if isURL(*filename) {
+		err = chip8.cpu.LoadROMURL(*filename)
+	} else {
+		err = chip8.cpu.LoadROM(filename)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	chip8.loaded(romName(*filename))
+
+	return nil
+}
+
+// LoadBuiltin loads one of the ROMs bundled with the emulator, listed by Builtins.
+func (chip8 *Chip8) LoadBuiltin(name string) error {
+	rom, err := BuiltinROM(name)
+	if err != nil {
+		return err
+	}
+
+	if err := chip8.cpu.LoadROMBytes(rom); err != nil {
+		return err
+	}
+
+	chip8.loaded(name)
+
+	return nil
+}
+
+// loaded sets things up for the ROM that was just loaded, called name.
+func (chip8 *Chip8) loaded(name string) {
+	// Use the quirks a known ROM needs, unless the user picked their own
+	if !chip8.quirksSet {
+		if known, ok := lookupROM(chip8.cpu.RAM[0x200 : 0x200+chip8.cpu.RS]); ok {
+			chip8.cpu.logf(LogInfo, "Detected %s, using its quirks: %+v\n", known.Name, known.Quirks)
+			chip8.applyQuirks(known.Quirks)
+		}
+	}
+
+	// Show which game is running
+	if ppu, ok := chip8.ppu.(*PPU); ok {
+		ppu.SetTitle(name)
+	}
+}
+
+// SetDisplay replaces the display frames are drawn on and keys read from, e.g. with a
+// NullDisplay after InitHeadless. The old display isn't destroyed.
+func (chip8 *Chip8) SetDisplay(display Display) {
+	chip8.ppu = display
+}
+
+// SetSound replaces what the beep is played on, e.g. with NullSound to run silently.
+func (chip8 *Chip8) SetSound(sound Sound) {
+	chip8.apu = sound
+}
+
+// SetQuirks selects the behaviour of instructions that differ between platforms.
+// Quirks set this way take precedence over those detected for known ROMs by Load.
+func (chip8 *Chip8) SetQuirks(quirks Quirks) {
+	chip8.applyQuirks(quirks)
+	chip8.quirksSet = true
+}
+
+// applyQuirks switches the CPU to quirks, resizing the address space to match.
+func (chip8 *Chip8) applyQuirks(quirks Quirks) {
+	chip8.cpu.Quirks = quirks
+	chip8.cpu.SetExtendedMemory(quirks.ExtendedMemory)
+}
+
+// Quirks returns the quirks in use, whether set by the user or detected by Load.
+func (chip8 *Chip8) Quirks() Quirks {
+	return chip8.cpu.Quirks
+}
+
+// Seed seeds the random number generator used by instruction Cxkk.
+// Combined with input playback, a seeded run is fully deterministic.
+func (chip8 *Chip8) Seed(seed int64) {
+	chip8.cpu.Seed(seed)
+}
+
+// SetPCCheck sets what happens when PC lands on an odd address, likely a bug in the ROM.
+func (chip8 *Chip8) SetPCCheck(check PCCheck) {
+	chip8.cpu.PCCheck = check
+}
+
+// SetLog sends the instruction trace and other messages to w instead of stdout. Crash dumps
+// still go to stderr.
+func (chip8 *Chip8) SetLog(w io.Writer) {
+	chip8.cpu.SetTrace(w)
+}
+
+// SetLogLevel sets how much is logged. Nothing is by default.
+func (chip8 *Chip8) SetLogLevel(level LogLevel) {
+	chip8.cpu.SetLogLevel(level)
+}
+
+// SetMuted silences the beep without affecting the sound timer.
+func (chip8 *Chip8) SetMuted(muted bool) {
+	if apu, ok := chip8.apu.(*APU); ok {
+		apu.SetMuted(muted)
+	}
+}
+
+// SetVolume sets the beep volume from 0.0 to 1.0.
+func (chip8 *Chip8) SetVolume(volume float64) {
+	if apu, ok := chip8.apu.(*APU); ok {
+		apu.SetVolume(volume)
+	}
+}
+
+// SetVSync paces frames by the display's refresh rate instead of a 60Hz ticker, presenting
+// every frame whatever fps is passed to Run. The timers still count down at 60Hz, and ipf
+// instructions are executed per presented frame. Only the SDL window waits for the refresh,
+// so other displays keep the ticker.
+func (chip8 *Chip8) SetVSync(enabled bool) error {
+	ppu, ok := chip8.ppu.(*PPU)
+	if !ok {
+		chip8.vsync = false
+		return nil
+	}
+
+	if err := ppu.SetVSync(enabled); err != nil {
+		return err
+	}
+
+	chip8.vsync = enabled
+	chip8.redraw = chip8.redraw || enabled
+
+	// The texture was recreated blank, so the next frame must redraw all of it
+	chip8.cpu.DF = true
+	chip8.cpu.dirty.markAll()
+
+	return nil
+}
+
+// SetBeepFrequency sets the pitch of the beep in Hz.
+func (chip8 *Chip8) SetBeepFrequency(hz float64) {
+	if apu, ok := chip8.apu.(*APU); ok {
+		apu.SetFrequency(hz)
+	}
+}
+
+// SetCycleLimit makes Run return after n instructions in total. 0 means no limit.
+func (chip8 *Chip8) SetCycleLimit(n uint64) {
+	chip8.cycleLimit = n
+}
+
+// SetSpeed changes how many instructions run per frame, overriding the ipf passed to Run from
+// the next frame on. 0 goes back to Run's ipf. It is safe to call from any goroutine, so the
+// speed can be changed while running.
+func (chip8 *Chip8) SetSpeed(cyclesPerFrame int) {
+	if cyclesPerFrame < 0 {
+		cyclesPerFrame = 0
+	}
+
+	chip8.speed.Store(int64(cyclesPerFrame))
+}
+
+// SetFade makes pixels that turn off fade out over the given number of frames, reducing flicker.
+// 0 keeps the default crisp display.
+func (chip8 *Chip8) SetFade(frames int) {
+	if ppu, ok := chip8.ppu.(*PPU); ok {
+		ppu.SetFade(frames)
+	}
+
+	// Fading pixels change every frame
+	chip8.redraw = frames > 0 || chip8.vsync
+}
+
+// SetColor sets the colour the display draws pixel value index in, from 0 to 3: the background,
+// XO-CHIP plane 0, plane 1 and both. Displays without colours ignore it.
+func (chip8 *Chip8) SetColor(index int, c color.RGBA) {
+	if display, ok := chip8.ppu.(colorDisplay); ok {
+		display.SetColor(index, c)
+
+		// Pixels outside the next changed region would keep the old colour
+		chip8.cpu.DF = true
+		chip8.cpu.dirty.markAll()
+	}
+}
+
+// SetColors draws lit pixels in fg on a bg background, e.g. amber on black. Only single plane
+// pixels change; the colours XO-CHIP's second plane adds are kept.
+func (chip8 *Chip8) SetColors(fg color.RGBA, bg color.RGBA) {
+	chip8.SetColor(0, bg)
+	chip8.SetColor(1, fg)
+}
+
+// SetScale sizes the window to show each CHIP-8 pixel as an n x n square, see PPU.SetScale.
+func (chip8 *Chip8) SetScale(n int) {
+	if ppu, ok := chip8.ppu.(*PPU); ok {
+		ppu.SetScale(n)
+	}
+}
+
+// SetPixelGap leaves a gap of the given number of window pixels between neighbouring pixels.
+// It only changes how the screen is presented.
+func (chip8 *Chip8) SetPixelGap(gap int) {
+	if ppu, ok := chip8.ppu.(*PPU); ok {
+		ppu.SetPixelGap(gap)
+	}
+}
+
+// SetKeymap changes the keyboard keys mapped to the keypad, see DefaultKeymap. Displays other
+// than the PPU keep their own keys.
+func (chip8 *Chip8) SetKeymap(keymap map[sdl.Scancode]byte) {
+	if ppu, ok := chip8.ppu.(*PPU); ok {
+		ppu.SetKeymap(keymap)
+	}
+}
+
+// RecordInput logs the keypad state of every frame to w.
+func (chip8 *Chip8) RecordInput(w io.Writer) {
+	chip8.recorder = NewInputRecorder(w)
+}
+
+// PlayInput replays a session recorded with RecordInput. Live keyboard input is ignored while playing.
+func (chip8 *Chip8) PlayInput(r io.Reader) error {
+	player, err := NewInputPlayer(r)
+	if err != nil {
 		return err
 	}
 
+	chip8.player = player
+
 	return nil
 }
 
-func (chip8 *Chip8) Run(fps int) {
+// Run runs the ROM at 60 frames per second, executing ipf instructions each frame and
+// presenting at most fps frames per second, until the window is closed, Stop is called or the
+// ROM fails, returning the error as RunContext does. It then shuts down.
+func (chip8 *Chip8) Run(fps int, ipf int) error {
+	defer chip8.Shutdown()
+
+	return chip8.RunContext(context.Background(), fps, ipf)
+}
+
+// Stop ends the run loop within a frame, as closing the window would, so Run and RunContext
+// return nil. Stopping is final: a later Run returns straight away. It is safe to call from any
+// goroutine, and more than once.
+func (chip8 *Chip8) Stop() {
+	chip8.stopClose.Do(func() {
+		close(chip8.stopped())
+	})
+}
+
+// stopped returns the channel closed by Stop.
+func (chip8 *Chip8) stopped() chan struct{} {
+	chip8.stopInit.Do(func() {
+		chip8.stop = make(chan struct{})
+	})
+
+	return chip8.stop
+}
+
+// RunContext runs the ROM until the window is closed or Stop is called, returning nil, or until
+// ctx is done, returning ctx.Err(). If an instruction fails, e.g. ErrUnknownInstruction, it
+// stops and returns the error, leaving the CPU as it was for DumpState. Either way the caller
+// is still responsible for calling Shutdown.
+//
+// Frames are emulated at a fixed 60Hz: input and sound are serviced and ipf instructions are
+// executed once per frame, so the CPU runs at 60 * ipf instructions per second. fps only caps
+// how many frames a second are presented, and can be lowered to save work without slowing
+// the game down.
+//
+// ctx is checked before every frame, so a deadline stops even a ROM stuck in a tight loop
+// within a frame of passing. Use context.WithTimeout to bound how long a ROM may run.
+func (chip8 *Chip8) RunContext(ctx context.Context, fps int, ipf int) error {
+	defer chip8.recoverCrash()
+
+	chip8.err = nil
+
 	// Print ROM for sanity sake
 	chip8.cpu.printRAM()
 
-	tick := time.Tick(time.Second / time.Duration(fps))
+	// Nothing else blocks on presenting, so running frames back to back would spin
+	if _, ok := chip8.ppu.(*PPU); ok && chip8.vsync {
+		chip8.present = presentClock{}
+		return chip8.runVSync(ctx, ipf)
+	}
+
+	chip8.frameTime = timerPeriod
+	chip8.present = presentClock{fps: fps}
+
+	ticker := time.NewTicker(chip8.frameTime)
+	defer ticker.Stop()
+
+	stop := chip8.stopped()
 
 	// Run ROM
 	for {
 		select {
-			// Routine that waits every `time.Second / time.Duration(fps)`
-			case <- tick:
+		case <-ctx.Done():
+			return ctx.Err()
 
-			// Emulate a cycle. Panic if error has occurred.
-			if err := chip8.cpu.Cycle(); err != nil {
-				panic(err)
+		case <-stop:
+			return nil
+
+		// Routine that waits every 1/60s
+		case <-ticker.C:
+			// select picks at random when both are ready, so a slow frame can't keep putting
+			// off cancellation
+			if err := ctx.Err(); err != nil {
+				return err
 			}
 
-			// Check draw flag
-			if chip8.cpu.DF {
-				// Draw
-				chip8.ppu.Draw(&chip8.cpu.GFX)
+			select {
+			case <-stop:
+				return nil
+			default:
+			}
 
-				// Don't forget to set the draw flag back
-				chip8.cpu.DF = false
+			if exit := chip8.runFrame(ipf); exit {
+				return chip8.err
 			}
+		}
+	}
+}
+
+// runVSync runs frames back to back, relying on presenting each frame to block until the
+// display's next refresh.
+func (chip8 *Chip8) runVSync(ctx context.Context, ipf int) error {
+	last := time.Now()
+	stop := chip8.stopped()
 
-			// Check keyboard input
-			if exit := chip8.ppu.Poll(&chip8.cpu.Key); exit {
-				break
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-stop:
+			return nil
+		default:
+		}
+
+		now := time.Now()
+		chip8.frameTime = now.Sub(last)
+		last = now
+
+		if exit := chip8.runFrame(ipf); exit {
+			return chip8.err
+		}
+	}
+}
+
+// runFrame emulates a single frame and reports whether to stop, either because the window
+// was closed, the cycle limit was reached or an error was left in chip8.err.
+func (chip8 *Chip8) runFrame(ipf int) bool {
+	limited := false
+
+	// Step back a frame instead of emulating one while the display asks to rewind
+	rewound := false
+	if display, ok := chip8.ppu.(rewindDisplay); ok && display.Rewinding() {
+		rewound = chip8.Rewind(1) > 0
+	}
+
+	if speed := chip8.speed.Load(); speed > 0 {
+		ipf = int(speed)
+	}
+
+	// Nothing runs while paused, but the screen and input are kept up
+	paused := chip8.paused.Load()
+	if !rewound && !paused {
+		limited = chip8.emulateFrame(ipf)
+	}
+
+	// Publish the frame's state for readers on other goroutines
+	chip8.takeSnapshot()
+
+	// Present no more often than the frame rate allows, but always present the last frame
+	// before stopping at the cycle limit or on an error
+	present := chip8.present.advance() || limited || chip8.err != nil
+
+	// Check draw flag. The last frame is drawn again while paused, e.g. for fading displays.
+	if (chip8.cpu.DF || chip8.redraw || paused) && present {
+		gfx := &chip8.cpu.GFX
+
+		// Draw, only the part that changed if the display can. Anything that set the draw flag
+		// without marking what changed gets a full redraw. The high resolution screen is halved
+		// for displays that can't show it.
+		region, partial := chip8.ppu.(regionDisplay)
+		hiRes, canHiRes := chip8.ppu.(hiResDisplay)
+
+		if chip8.cpu.HiRes {
+			// OnDraw gets the halved screen either way
+			low := downscale(&chip8.cpu.HiGFX)
+			gfx = &low
+
+			if canHiRes {
+				hiRes.DrawHiRes(&chip8.cpu.HiGFX)
+			} else {
+				chip8.ppu.Draw(gfx)
 			}
+		} else if partial && !chip8.redraw && !chip8.cpu.dirty.full && !chip8.cpu.dirty.empty() {
+			x, y, w, h := chip8.cpu.dirty.bounds(64, 32)
+			region.DrawRegion(gfx, x, y, w, h)
+		} else {
+			chip8.ppu.Draw(gfx)
+		}
 
-			// Emulate sound/beep
-			if chip8.cpu.ST > 0 {
-				chip8.apu.beep()
+		chip8.cpu.dirty.reset()
+		chip8.present.presented()
+		chip8.recordScreen()
+
+		if chip8.OnDraw != nil {
+			chip8.OnDraw(gfx)
+		}
+
+		// Don't forget to set the draw flag back
+		chip8.cpu.DF = false
+	}
+
+	// Present the last frame before stopping at the cycle limit or on an error
+	if limited || chip8.err != nil {
+		return true
+	}
+
+	// Check keyboard input
+	if exit := chip8.ppu.Poll(&chip8.cpu.Key); exit {
+		return true
+	}
+
+	// Replay recorded input over the live keyboard, or record it
+	if chip8.player != nil {
+		chip8.player.Play(chip8.frame, &chip8.cpu.Key)
+	} else if chip8.recorder != nil {
+		if err := chip8.recorder.Record(chip8.frame, &chip8.cpu.Key); err != nil {
+			chip8.err = fmt.Errorf("record input: %w", err)
+			return true
+		}
+	}
+
+	chip8.frame++
+
+	// Measure speed for displays with an overlay
+	if stats, ok := chip8.ppu.(statsDisplay); ok {
+		chip8.meter.add(time.Now(), chip8.cpu.CycleCount())
+		stats.SetStats(chip8.meter.rates())
+	}
+
+	// XO-CHIP ROMs play their own waveform
+	if apu, ok := chip8.apu.(*APU); ok && chip8.cpu.patternLoaded {
+		apu.SetPattern(&chip8.cpu.Pattern, chip8.cpu.Pitch)
+	}
+
+	// Keep the tone going
+	if apu, ok := chip8.apu.(*APU); ok {
+		apu.update()
+	}
+
+	// Emulate sound/beep, playing for as long as the sound timer runs. The timer is frozen while
+	// paused, so the beep stops.
+	if beeping := chip8.cpu.ST > 0 && !paused; beeping != chip8.beeping {
+		chip8.beeping = beeping
+
+		if beeping {
+			chip8.apu.On()
+		} else {
+			chip8.apu.Off()
+		}
+
+		if chip8.OnBeep != nil {
+			chip8.OnBeep(beeping)
+		}
+	}
+
+	return false
+}
+
+// emulateFrame executes the instructions of a frame and counts the timers down, reporting
+// whether it stopped at the cycle limit. An instruction that fails ends the frame early, with
+// its error left in chip8.err.
+func (chip8 *Chip8) emulateFrame(ipf int) bool {
+	limited := false
+
+	// A new frame ends any wait for the vertical blank
+	chip8.cpu.vblankWait = false
+
+	// Emulate ipf cycles, or fewer if a draw waits for the vertical blank or an error occurs
+	for i := 0; i < ipf && !chip8.cpu.vblankWait; i++ {
+		if chip8.cycleLimit > 0 && chip8.cpu.CycleCount() >= chip8.cycleLimit {
+			limited = true
+			break
+		}
+
+		// Read the opcode before it runs, as it may write over itself
+		pc := chip8.cpu.PC
+		opCode := uint16(chip8.cpu.RAM[chip8.cpu.addr(pc)])<<8 | uint16(chip8.cpu.RAM[chip8.cpu.addr(pc+1)])
+
+		var hit *Break
+		var err error
+
+		if chip8.debugger != nil {
+			hit, err = chip8.debugger.step()
+		} else {
+			err = chip8.cpu.Cycle()
+		}
+
+		if err != nil {
+			chip8.err = err
+			return false
+		}
+
+		if chip8.OnCycle != nil {
+			chip8.OnCycle(pc, opCode)
+		}
+
+		// Stop until Resume
+		if hit != nil {
+			chip8.paused.Store(true)
+
+			if chip8.OnBreak != nil {
+				chip8.OnBreak(hit)
 			}
+			break
 		}
 	}
+
+	// Count the timers down at 60Hz
+	frameTime := chip8.frameTime
+	if frameTime == 0 {
+		frameTime = timerPeriod
+	}
+
+	for n := chip8.timers.advance(frameTime); n > 0; n-- {
+		chip8.cpu.tickTimers()
+	}
+
+	// Keep the frame for rewinding
+	if chip8.rewind != nil {
+		chip8.rewind.push(chip8.cpu)
+	}
+
+	return limited
 }
 
+// DumpGFX writes the screen to w as text, one row per line with '#' for lit pixels, at the active resolution.
+func (chip8 *Chip8) DumpGFX(w io.Writer) {
+	chip8.cpu.DumpGFX(w)
+}
+
+// recoverCrash dumps the CPU state when the run loop panics, e.g. on a bug in an instruction,
+// so a crash report says what the ROM was doing. It shuts the display down, then panics again.
+func (chip8 *Chip8) recoverCrash() {
+	cause := recover()
+	if cause == nil {
+		return
+	}
+
+	w := chip8.crashLog
+	if w == nil {
+		w = os.Stderr
+	}
+
+	chip8.Shutdown()
+
+	fmt.Fprintf(w, "\nCHIP-8 crashed: %v\n", cause)
+	chip8.DumpState(w)
 
+	panic(cause)
+}
+
+// DumpState writes PC and the opcode there, the registers and the call stack to w, e.g. to
+// report what the ROM was doing when RunContext returned an error.
+func (chip8 *Chip8) DumpState(w io.Writer) {
+	cpu := chip8.cpu
+	fmt.Fprintf(w, "PC: %d     OpCode: %04X\n", cpu.PC, uint16(cpu.RAM[cpu.addr(cpu.PC)])<<8|uint16(cpu.RAM[cpu.addr(cpu.PC+1)]))
+	cpu.DumpRegisters(w)
+	fmt.Fprintf(w, "Call stack: %v\n", cpu.CallStack())
+}
+
+// Shutdown tears down the sound and the display. It is safe to call more than once, e.g. from both
+// a signal handler and the window's quit path.
 func (chip8 *Chip8) Shutdown() {
-	chip8.ppu.destroy()
+	chip8.shutdown.Do(func() {
+		// The APU needs SDL, which the PPU shuts down
+		if apu, ok := chip8.apu.(*APU); ok {
+			apu.Close()
+		}
+
+		chip8.ppu.Destroy()
+	})
 }
@@ -0,0 +1,591 @@
+package CHIP8
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeDisplay records the calls the run loop makes so tests can check their order.
+type fakeDisplay struct {
+	calls []string
+	polls int
+	poll  func(polls int, key *[16]bool) bool
+}
+
+func (display *fakeDisplay) Draw(gfx *[32][64]byte) {
+	display.calls = append(display.calls, "draw")
+}
+
+func (display *fakeDisplay) Poll(key *[16]bool) bool {
+	display.calls = append(display.calls, "poll")
+	display.polls++
+
+	if display.poll != nil {
+		return display.poll(display.polls, key)
+	}
+
+	return false
+}
+
+func (display *fakeDisplay) Destroy() {
+	display.calls = append(display.calls, "destroy")
+}
+
+// newTestChip8 builds a Chip8 around display with an endless loop of 6xkk loads at 0x200.
+func newTestChip8(display *fakeDisplay) *Chip8 {
+	cpu := &CPU{}
+	cpu.Init()
+	cpu.PC = 0x200
+
+	for i := 0x200; i < 0xFFE; i += 2 {
+		cpu.RAM[i] = 0x60
+	}
+
+	// Loop back rather than run off the top of memory
+	cpu.RAM[0xFFE] = 0x12
+	cpu.RAM[0xFFF] = 0x00
+
+	return &Chip8{cpu: cpu, ppu: display, apu: NullSound{}}
+}
+
+func TestRunContextShutdownOrder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Simulate a SIGINT arriving on the third frame
+	display := &fakeDisplay{poll: func(polls int, key *[16]bool) bool {
+		if polls == 3 {
+			cancel()
+		}
+		return false
+	}}
+	chip8 := newTestChip8(display)
+
+	if err := chip8.RunContext(ctx, 1000, 1); err != context.Canceled {
+		t.Errorf("TestRunContextShutdownOrder: unexpected error. Expected: %v Received: %v", context.Canceled, err)
+	}
+
+	// Both the signal path and the quit path may call Shutdown
+	chip8.Shutdown()
+	chip8.Shutdown()
+
+	expected := []string{"poll", "poll", "poll", "destroy"}
+	if len(display.calls) != len(expected) {
+		t.Fatalf("TestRunContextShutdownOrder: unexpected calls. Expected: %v Received: %v", expected, display.calls)
+	}
+
+	for i := range expected {
+		if display.calls[i] != expected[i] {
+			t.Errorf("TestRunContextShutdownOrder: unexpected calls. Expected: %v Received: %v", expected, display.calls)
+			break
+		}
+	}
+}
+
+func TestRunContextQuitEvent(t *testing.T) {
+	display := &fakeDisplay{poll: func(polls int, key *[16]bool) bool {
+		return polls == 2
+	}}
+	chip8 := newTestChip8(display)
+
+	if err := chip8.RunContext(context.Background(), 1000, 1); err != nil {
+		t.Errorf("TestRunContextQuitEvent: unexpected error on quit: %v", err)
+	}
+
+	if display.polls != 2 {
+		t.Errorf("TestRunContextQuitEvent: kept running after quit. Expected polls: %d Received: %d", 2, display.polls)
+	}
+}
+
+func TestRunFrameInstructionsPerFrame(t *testing.T) {
+	chip8 := newTestChip8(&fakeDisplay{})
+
+	// Every instruction in the test ROM advances PC by 2
+	for frame := 1; frame <= 3; frame++ {
+		chip8.runFrame(11)
+
+		if expected := uint16(0x200 + frame*11*2); chip8.cpu.PC != expected {
+			t.Errorf("TestRunFrameInstructionsPerFrame: unexpected PC after frame %d. Expected: %d Received: %d", frame, expected, chip8.cpu.PC)
+		}
+	}
+
+	display := &fakeDisplay{poll: func(polls int, key *[16]bool) bool {
+		return polls == 4
+	}}
+	chip8 = newTestChip8(display)
+	chip8.RunContext(context.Background(), 1000, 7)
+
+	if expected := uint16(0x200 + 4*7*2); chip8.cpu.PC != expected {
+		t.Errorf("TestRunFrameInstructionsPerFrame: unexpected PC after 4 frames. Expected: %d Received: %d", expected, chip8.cpu.PC)
+	}
+}
+
+func TestRunFrameLoadKey(t *testing.T) {
+	chip8 := newTestChip8(&fakeDisplay{})
+	copy(chip8.cpu.RAM[0x200:], []byte{
+		0x60, 0x3C, // 200: V0 = 60
+		0xF0, 0x15, // 202: DT = V0
+		0xF3, 0x0A, // 204: V3 = key
+		0x12, 0x06, // 206: jump 206
+	})
+
+	// Waiting for a key ends each frame, but the timers keep counting down
+	for frame := 1; frame <= 5; frame++ {
+		chip8.runFrame(11)
+
+		if chip8.cpu.PC != 0x204 || chip8.cpu.DT != byte(60-frame) {
+			t.Fatalf("TestRunFrameLoadKey: unexpected state while waiting on frame %d. Expected PC: %d DT: %d Received PC: %d DT: %d",
+				frame, 0x204, 60-frame, chip8.cpu.PC, chip8.cpu.DT)
+		}
+	}
+
+	chip8.SetKey(0xB, true)
+	chip8.runFrame(11)
+
+	if chip8.cpu.V[0x3] != 0xB || chip8.cpu.PC != 0x206 {
+		t.Errorf("TestRunFrameLoadKey: failed to load the key. Expected V3: %d PC: %d Received V3: %d PC: %d", 0xB, 0x206, chip8.cpu.V[0x3], chip8.cpu.PC)
+	}
+
+	if chip8.cpu.DT != 54 {
+		t.Errorf("TestRunFrameLoadKey: unexpected DT. Expected: %d Received: %d", 54, chip8.cpu.DT)
+	}
+}
+
+func TestRunContextTimeout(t *testing.T) {
+	// 1200 jumps to itself forever and never draws, so only the deadline can stop it
+	chip8 := newTestChip8(&fakeDisplay{})
+	chip8.cpu.RAM[0x200] = 0x12
+	chip8.cpu.RAM[0x201] = 0x00
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := chip8.RunContext(ctx, 1000, 1000)
+	elapsed := time.Since(start)
+
+	if err != context.DeadlineExceeded {
+		t.Errorf("TestRunContextTimeout: unexpected error. Expected: %v Received: %v", context.DeadlineExceeded, err)
+	}
+
+	if elapsed > time.Second {
+		t.Errorf("TestRunContextTimeout: returned too long after the deadline. Expected: %v Received: %v", 50*time.Millisecond, elapsed)
+	}
+
+	if chip8.cpu.PC != 0x200 {
+		t.Errorf("TestRunContextTimeout: left the spin loop. Expected PC: %d Received: %d", 0x200, chip8.cpu.PC)
+	}
+}
+
+func TestRunFrameTimers(t *testing.T) {
+	// A second at 120fps counts the timers down 60 times, however many instructions run
+	for _, ipf := range []int{1, 11, 500} {
+		chip8 := newTestChip8(&fakeDisplay{})
+		chip8.frameTime = time.Second / 120
+		chip8.cpu.DT = 200
+
+		for frame := 0; frame < 120; frame++ {
+			chip8.runFrame(ipf)
+		}
+
+		if expected := byte(200 - 60); chip8.cpu.DT != expected {
+			t.Errorf("TestRunFrameTimers: unexpected DT after a second at %d instructions per frame. Expected: %d Received: %d", ipf, expected, chip8.cpu.DT)
+		}
+	}
+
+	// Instructions alone don't touch the timers
+	cpu := &CPU{}
+	cpu.Init()
+	cpu.PC = 0x200
+	cpu.RAM[0x200] = 0x60
+	cpu.DT = 10
+
+	if cpu.Cycle(); cpu.DT != 10 {
+		t.Errorf("TestRunFrameTimers: Cycle changed DT. Expected: %d Received: %d", 10, cpu.DT)
+	}
+}
+
+func TestRunContextFrameRate(t *testing.T) {
+	// A fifth of a second is 12 frames at 60Hz, whatever the frame rate
+	for _, fps := range []int{20, 30, 60, 120} {
+		display := &fakeDisplay{}
+		chip8 := newTestChip8(display)
+		chip8.redraw = true
+		chip8.SetCycleLimit(12 * 10)
+
+		if err := chip8.RunContext(context.Background(), fps, 10); err != nil {
+			t.Fatalf("TestRunContextFrameRate: unexpected error at %dfps: %v", fps, err)
+		}
+
+		if chip8.frame != 12 || chip8.cpu.CycleCount() != 12*10 {
+			t.Errorf("TestRunContextFrameRate: fps changed the emulation speed. Frames: %d Cycles: %d at %dfps", chip8.frame, chip8.cpu.CycleCount(), fps)
+		}
+
+		// Only fps of every 60 frames are drawn, plus the last one at the cycle limit
+		rate := fps
+		if rate > 60 {
+			rate = 60
+		}
+
+		draws := strings.Count(strings.Join(display.calls, " "), "draw")
+		if expected := 12*rate/60 + 1; draws != expected {
+			t.Errorf("TestRunContextFrameRate: unexpected number of draws at %dfps. Expected: %d Received: %d", fps, expected, draws)
+		}
+	}
+}
+
+// regionFakeDisplay is a fakeDisplay with a palette that can redraw part of the screen.
+type regionFakeDisplay struct {
+	fakeDisplay
+}
+
+func (display *regionFakeDisplay) DrawRegion(gfx *[32][64]byte, x int, y int, w int, h int) {
+	display.calls = append(display.calls, "draw region")
+}
+
+func (display *regionFakeDisplay) SetColor(index int, c color.RGBA) {
+	display.calls = append(display.calls, "set color")
+}
+
+func TestSetColorRedraws(t *testing.T) {
+	rom := []byte{
+		0xA0, 0x00, // 200: I = font digit 0
+		0xD0, 0x05, // 202: draw it at (0, 0)
+		0x12, 0x04, // 204: jump 204
+	}
+
+	display := &regionFakeDisplay{}
+	chip8 := newTestChip8(&display.fakeDisplay)
+	chip8.ppu = display
+	copy(chip8.cpu.RAM[0x200:], rom)
+
+	chip8.runFrame(3)
+	if display.calls[0] != "draw region" {
+		t.Fatalf("TestSetColorRedraws: failed to draw only the sprite. Calls: %v", display.calls)
+	}
+
+	// Nothing changes on screen, but every pixel has to be drawn in the new colour
+	chip8.SetColor(1, color.RGBA{0xFF, 0xB0, 0x00, 0xFF})
+	display.calls = nil
+	chip8.runFrame(3)
+	if display.calls[0] != "draw" {
+		t.Errorf("TestSetColorRedraws: failed to redraw the whole screen. Calls: %v", display.calls)
+	}
+}
+
+func TestSetVSyncWithoutWindow(t *testing.T) {
+	display := &fakeDisplay{poll: func(polls int, key *[16]bool) bool { return polls == 3 }}
+	chip8 := newTestChip8(display)
+
+	if err := chip8.SetVSync(true); err != nil || chip8.vsync || chip8.redraw {
+		t.Errorf("TestSetVSyncWithoutWindow: enabled vsync without a window. VSync: %t Error: %v", chip8.vsync, err)
+	}
+
+	// Even if vsync was set before the display was replaced, the ticker paces the frames
+	chip8.vsync = true
+	start := time.Now()
+
+	if err := chip8.RunContext(context.Background(), 1000, 1); err != nil {
+		t.Fatalf("TestSetVSyncWithoutWindow: unexpected error: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 3*timerPeriod {
+		t.Errorf("TestSetVSyncWithoutWindow: ran 3 frames in %v, faster than 60 a second", elapsed)
+	}
+}
+
+func TestRunContextCycleLimit(t *testing.T) {
+	display := &fakeDisplay{}
+	chip8 := newTestChip8(display)
+	chip8.SetCycleLimit(25)
+
+	// 25 isn't a multiple of the 11 instructions per frame, so the limit lands mid-frame. Run
+	// shuts down on its own once the limit is reached.
+	if err := chip8.Run(1000, 11); err != nil {
+		t.Fatalf("TestRunContextCycleLimit: unexpected error: %v", err)
+	}
+
+	if expected := uint16(0x200 + 25*2); chip8.cpu.PC != expected {
+		t.Errorf("TestRunContextCycleLimit: failed to stop at the limit. Expected PC: %d Received: %d", expected, chip8.cpu.PC)
+	}
+
+	if last := display.calls[len(display.calls)-1]; last != "destroy" {
+		t.Errorf("TestRunContextCycleLimit: failed to shut down the display. Calls: %v", display.calls)
+	}
+}
+
+func TestWindowTitle(t *testing.T) {
+	cases := map[string]string{
+		"roms/PONG.ch8":       "CHIP-8 — PONG",
+		"INVADERS":            "CHIP-8 — INVADERS",
+		"/games/tetris.v2.c8": "CHIP-8 — tetris.v2",
+		"":                    "CHIP-8",
+	}
+
+	for filename, expected := range cases {
+		if received := windowTitle(romName(filename)); received != expected {
+			t.Errorf("TestWindowTitle: unexpected title for %q. Expected: %q Received: %q", filename, expected, received)
+		}
+	}
+}
+
+func TestCallbacks(t *testing.T) {
+	chip8 := newTestChip8(&fakeDisplay{})
+	copy(chip8.cpu.RAM[0x200:], []byte{
+		0x60, 0x02, // 200: V0 = 2
+		0xF0, 0x18, // 202: ST = V0
+		0x00, 0xE0, // 204: clear
+		0x12, 0x06, // 206: jump 206
+	})
+
+	var pcs []uint16
+	var opCodes []uint16
+	draws := 0
+	var beeps []bool
+
+	chip8.OnCycle = func(pc uint16, opCode uint16) {
+		pcs = append(pcs, pc)
+		opCodes = append(opCodes, opCode)
+	}
+	chip8.OnDraw = func(gfx *[32][64]byte) {
+		draws++
+	}
+	chip8.OnBeep = func(on bool) {
+		beeps = append(beeps, on)
+	}
+
+	// The sound timer counts down once per frame, so it runs out at the end of the second frame
+	chip8.runFrame(3)
+	chip8.runFrame(3)
+
+	expectedPCs := []uint16{0x200, 0x202, 0x204, 0x206, 0x206, 0x206}
+	if len(pcs) != len(expectedPCs) {
+		t.Fatalf("TestCallbacks: unexpected number of cycles. Expected: %d Received: %d", len(expectedPCs), len(pcs))
+	}
+
+	for i := range expectedPCs {
+		if pcs[i] != expectedPCs[i] {
+			t.Errorf("TestCallbacks: unexpected PC for cycle %d. Expected: %d Received: %d", i, expectedPCs[i], pcs[i])
+		}
+	}
+
+	if opCodes[1] != 0xF018 || opCodes[5] != 0x1206 {
+		t.Errorf("TestCallbacks: unexpected opcodes. Received: %X", opCodes)
+	}
+
+	if draws != 1 {
+		t.Errorf("TestCallbacks: unexpected number of draws. Expected: %d Received: %d", 1, draws)
+	}
+
+	if len(beeps) != 2 || !beeps[0] || beeps[1] {
+		t.Errorf("TestCallbacks: expected the beep to start and then stop. Received: %v", beeps)
+	}
+}
+
+func TestOnCycleSelfModifying(t *testing.T) {
+	chip8 := newTestChip8(&fakeDisplay{})
+	copy(chip8.cpu.RAM[0x200:], []byte{
+		0x60, 0xAB, // 200: V0 = AB
+		0xA2, 0x04, // 202: I = 204
+		0xF0, 0x55, // 204: store V0 at I, over this instruction
+	})
+
+	var opCodes []uint16
+	chip8.OnCycle = func(pc uint16, opCode uint16) {
+		opCodes = append(opCodes, opCode)
+	}
+
+	chip8.runFrame(3)
+
+	if len(opCodes) != 3 || opCodes[2] != 0xF055 || chip8.cpu.RAM[0x204] != 0xAB {
+		t.Errorf("TestOnCycleSelfModifying: unexpected opcodes. Expected the last to be %X. Received: %X", 0xF055, opCodes)
+	}
+}
+
+func TestRunContextError(t *testing.T) {
+	display := &fakeDisplay{}
+	chip8 := newTestChip8(display)
+	copy(chip8.cpu.RAM[0x200:], []byte{
+		0x22, 0x04, // 200: call 204
+		0x00, 0x00, // 202:
+		0x6A, 0x42, // 204: VA = 0x42
+		0xFF, 0xFF, // 206: unknown instruction
+	})
+
+	err := chip8.RunContext(context.Background(), 1000, 11)
+	if !errors.Is(err, ErrUnknownInstruction) {
+		t.Fatalf("TestRunContextError: unexpected error. Expected: %v Received: %v", ErrUnknownInstruction, err)
+	}
+
+	// The CPU is left at the failing instruction
+	var dump bytes.Buffer
+	chip8.DumpState(&dump)
+
+	out := dump.String()
+	for _, expected := range []string{"PC: 518     OpCode: FFFF", "VA: 42", "Call stack: [512]"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("TestRunContextError: dump is missing %q.\n%s", expected, out)
+		}
+	}
+
+	// It stops without polling again, and shutting down is left to the caller
+	if len(display.calls) != 0 {
+		t.Errorf("TestRunContextError: unexpected display calls. Expected: none Received: %v", display.calls)
+	}
+
+	// Run shuts down itself
+	display = &fakeDisplay{}
+	chip8 = newTestChip8(display)
+	chip8.cpu.RAM[0x200] = 0xFF
+	chip8.cpu.RAM[0x201] = 0xFF
+
+	if err := chip8.Run(1000, 11); !errors.Is(err, ErrUnknownInstruction) {
+		t.Errorf("TestRunContextError: unexpected error from Run. Expected: %v Received: %v", ErrUnknownInstruction, err)
+	}
+
+	if last := display.calls[len(display.calls)-1]; last != "destroy" {
+		t.Errorf("TestRunContextError: Run failed to shut down. Calls: %v", display.calls)
+	}
+}
+
+func TestRunContextCrashDump(t *testing.T) {
+	display := &fakeDisplay{}
+	chip8 := newTestChip8(display)
+	copy(chip8.cpu.RAM[0x200:], []byte{
+		0x22, 0x04, // 200: call 204
+		0x00, 0x00, // 202:
+		0x6A, 0x42, // 204: VA = 0x42
+	})
+
+	// Panic on a bug rather than an error, after VA is set
+	chip8.OnCycle = func(pc uint16, opCode uint16) {
+		if opCode == 0x6A42 {
+			panic("bug")
+		}
+	}
+
+	var dump bytes.Buffer
+	chip8.crashLog = &dump
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("TestRunContextCrashDump: expected the crash to panic again after dumping")
+			}
+		}()
+
+		chip8.RunContext(context.Background(), 1000, 11)
+	}()
+
+	out := dump.String()
+	for _, expected := range []string{"CHIP-8 crashed: bug", "PC: 518", "VA: 42", "Call stack: [512]"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("TestRunContextCrashDump: dump is missing %q.\n%s", expected, out)
+		}
+	}
+
+	if last := display.calls[len(display.calls)-1]; last != "destroy" {
+		t.Errorf("TestRunContextCrashDump: failed to shut down the display. Calls: %v", display.calls)
+	}
+}
+
+func TestRunFrameDisplayWait(t *testing.T) {
+	rom := []byte{
+		0xA0, 0x00, // 200: I = sprite for 0
+		0xD0, 0x15, // 202: draw
+		0x70, 0x01, // 204: V0 += 1
+		0x12, 0x02, // 206: jump 202
+	}
+
+	draws := func(quirks Quirks) []int {
+		chip8 := newTestChip8(&fakeDisplay{})
+		chip8.cpu.Quirks = quirks
+		copy(chip8.cpu.RAM[0x200:], rom)
+
+		var counts []int
+		chip8.OnCycle = func(pc uint16, opCode uint16) {
+			if opCode&0xF000 == 0xD000 {
+				counts[len(counts)-1]++
+			}
+		}
+
+		for frame := 0; frame < 5; frame++ {
+			counts = append(counts, 0)
+			chip8.runFrame(20)
+		}
+
+		return counts
+	}
+
+	for frame, count := range draws(Quirks{DisplayWait: true}) {
+		if count != 1 {
+			t.Errorf("TestRunFrameDisplayWait: unexpected draws in frame %d with the quirk on. Expected: %d Received: %d", frame, 1, count)
+		}
+	}
+
+	// Without the quirk the loop draws every third instruction, 6 times in the second frame
+	if counts := draws(Quirks{}); counts[1] != 6 {
+		t.Errorf("TestRunFrameDisplayWait: unexpected draws in a frame with the quirk off. Expected: %d Received: %d", 6, counts[1])
+	}
+}
+
+func TestSetSpeed(t *testing.T) {
+	chip8 := newTestChip8(&fakeDisplay{})
+
+	counts := []uint64{}
+	for _, speed := range []int{0, 20, 3, 0} {
+		// Change speed from another goroutine between frames, as a front-end would
+		done := make(chan struct{})
+		go func() {
+			chip8.SetSpeed(speed)
+			close(done)
+		}()
+		<-done
+
+		before := chip8.cpu.CycleCount()
+		chip8.runFrame(7)
+		counts = append(counts, chip8.cpu.CycleCount()-before)
+	}
+
+	// 0 runs the ipf given to Run, here 7
+	if expected := []uint64{7, 20, 3, 7}; fmt.Sprint(counts) != fmt.Sprint(expected) {
+		t.Errorf("TestSetSpeed: unexpected instructions per frame. Expected: %v Received: %v", expected, counts)
+	}
+}
+
+func TestStop(t *testing.T) {
+	display := &fakeDisplay{}
+	chip8 := newTestChip8(display)
+
+	done := make(chan struct{})
+	go func() {
+		chip8.Run(1000, 1)
+		close(done)
+	}()
+
+	// Give the loop a few frames before stopping it
+	time.Sleep(20 * time.Millisecond)
+	chip8.Stop()
+	chip8.Stop()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("TestStop: Run didn't return after Stop")
+	}
+
+	if last := display.calls[len(display.calls)-1]; last != "destroy" {
+		t.Errorf("TestStop: failed to shut down. Calls: %v", display.calls)
+	}
+
+	// Stopping is final
+	if err := chip8.RunContext(context.Background(), 1000, 1); err != nil {
+		t.Errorf("TestStop: unexpected error running after Stop: %v", err)
+	}
+}
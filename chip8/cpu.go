@@ -1,83 +1,246 @@
 package CHIP8
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
 	"fmt"
-	"github.com/veandco/go-sdl2/sdl"
+	"io"
 	"io/ioutil"
 	"math/rand"
+	"os"
+	"time"
 )
 
+// ErrUnknownInstruction is returned by Cycle for an opcode that doesn't decode to any instruction.
+var ErrUnknownInstruction = errors.New("unknown instruction")
+
+// ErrStackOverflow is returned by Cycle for a 2nnn call with all 16 stack levels in use.
+var ErrStackOverflow = errors.New("stack overflow")
+
+// ErrStackUnderflow is returned by Cycle for a 00EE return with nothing on the stack.
+var ErrStackUnderflow = errors.New("stack underflow")
+
+// The hexadecimal font, 5 bytes per digit 0 - F, loaded at the start of RAM.
+var font = [80]byte{0xF0, 0x90, 0x90, 0x90, 0xF0, // 0
+	0x20, 0x60, 0x20, 0x20, 0x70, // 1
+	0xF0, 0x10, 0xF0, 0x80, 0xF0, // 2
+	0xF0, 0x10, 0xF0, 0x10, 0xF0, // 3
+	0xA0, 0xA0, 0xF0, 0x20, 0x20, // 4
+	0xF0, 0x80, 0xF0, 0x10, 0xF0, // 5
+	0xF0, 0x80, 0xF0, 0x90, 0xF0, // 6
+	0xF0, 0x10, 0x20, 0x40, 0x40, // 7
+	0xF0, 0x90, 0xF0, 0x90, 0xF0, // 8
+	0xF0, 0x90, 0xF0, 0x10, 0xF0, // 9
+	0xF0, 0x90, 0xF0, 0x90, 0x90, // A
+	0xE0, 0x90, 0xE0, 0x90, 0xE0, // B
+	0xF0, 0x80, 0x80, 0x80, 0xF0, // C
+	0xE0, 0x90, 0x90, 0x90, 0xE0, // D
+	0xF0, 0x80, 0xF0, 0x80, 0xF0, // E
+	0xF0, 0x80, 0xF0, 0x80, 0x80} // F
+
+// SUPER-CHIP 8x10 font for instruction Fx30, loaded after the small font.
+var bigFont = [160]byte{0x3C, 0x7E, 0xE7, 0xC3, 0xC3, 0xC3, 0xC3, 0xE7, 0x7E, 0x3C, // 0
+	0x18, 0x38, 0x58, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x3C, // 1
+	0x3E, 0x7F, 0xC3, 0x06, 0x0C, 0x18, 0x30, 0x60, 0xFF, 0xFF, // 2
+	0x3C, 0x7E, 0xC3, 0x03, 0x0E, 0x0E, 0x03, 0xC3, 0x7E, 0x3C, // 3
+	0x06, 0x0E, 0x1E, 0x36, 0x66, 0xC6, 0xFF, 0xFF, 0x06, 0x06, // 4
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFC, 0xFE, 0x03, 0xC3, 0x7E, 0x3C, // 5
+	0x3E, 0x7C, 0xE0, 0xC0, 0xFC, 0xFE, 0xC3, 0xC3, 0x7E, 0x3C, // 6
+	0xFF, 0xFF, 0x03, 0x06, 0x0C, 0x18, 0x30, 0x60, 0x60, 0x60, // 7
+	0x3C, 0x7E, 0xC3, 0xC3, 0x7E, 0x7E, 0xC3, 0xC3, 0x7E, 0x3C, // 8
+	0x3C, 0x7E, 0xC3, 0xC3, 0x7F, 0x3F, 0x03, 0x03, 0x3E, 0x7C, // 9
+	0x7E, 0xFF, 0xC3, 0xC3, 0xC3, 0xFF, 0xFF, 0xC3, 0xC3, 0xC3, // A
+	0xFC, 0xFC, 0xC3, 0xC3, 0xFC, 0xFC, 0xC3, 0xC3, 0xFC, 0xFC, // B
+	0x3C, 0xFF, 0xC3, 0xC0, 0xC0, 0xC0, 0xC0, 0xC3, 0xFF, 0x3C, // C
+	0xFC, 0xFE, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xFE, 0xFC, // D
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, // E
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC0, 0xC0, 0xC0, 0xC0} // F
+
 type CPU struct {
-	RAM   [4096]byte   // CHIP-8 is capable of accessing 4KB (4,096 bytes) of RAM.
-	GFX   [32][64]byte // CHIP-8 screen is 64x32 pixels.
-	Stack [16]uint16   // 16 16-bit stack used for saving addresses before subroutines.
+	RAM   [65536]byte   // CHIP-8 is capable of accessing 4KB (4,096 bytes) of RAM, XO-CHIP 64KB.
+	GFX   [32][64]byte  // CHIP-8 screen is 64x32 pixels.
+	HiGFX [64][128]byte // SUPER-CHIP high resolution screen, used instead of GFX while HiRes is set.
+	HiRes bool          // Whether the 128x64 screen is in use, switched by 00FF and 00FE.
+	Stack [16]uint16    // 16 16-bit stack used for saving addresses before subroutines.
 
 	V [16]byte // 16 8-bit Registers: V0 - VE are general registers and VF is a flag register.
 
 	PC uint16 // 16-bit Program counter. All programs start at 0x200.
 	SP uint16 // 16-bit Stack pointer
-	I  uint   // Address register
+	I  uint16 // 16-bit Address register, wrapped to the active RAM size
 
 	DT byte // Delay timer
 	ST byte // Sound timer
 
-	Key    [16]bool
-	keypad map[sdl.Scancode]byte
+	Pattern       [16]byte // XO-CHIP audio pattern, 128 1-bit samples played while ST is non-zero
+	Pitch         byte     // XO-CHIP pitch register, sets the pattern playback rate
+	patternLoaded bool     // Whether the ROM has loaded an audio pattern, rather than using the beep
+
+	Key [16]bool
+
+	Flags [8]byte // SUPER-CHIP RPL user flags, stored by Fx75 and read by Fx85
 
 	RS int  // ROM Size: length of CHIP-8 program byte array
 	DF bool // Draw Flag
+
+	fontBase    uint16 // Address of the 4x5 hex font used by Fx29
+	bigFontBase uint16 // Address of the SUPER-CHIP 8x10 font used by Fx30
+
+	// Copies of the fonts, restored by Reset in case a ROM overwrote them
+	font    []byte
+	bigFont []byte
+
+	cycles     uint64    // Number of instructions executed since power on or Reset
+	vblankWait bool      // Waiting for the next frame: Dxyn with the DisplayWait quirk, or Fx0A for a key
+	dirty      dirtyRect // Part of the screen changed since it was last drawn
+
+	rng *rand.Rand // Random number source for instruction Cxkk
+
+	Quirks Quirks // Platform specific instruction behaviour
+
+	trace    io.Writer // Where the instruction trace goes, os.Stdout if nil
+	logLevel LogLevel  // How much goes to trace, nothing by default
+
+	PCCheck PCCheck         // What to do when PC is odd, see PCCheck
+	warnLog io.Writer       // Where PCWarn warnings go, os.Stderr if nil
+	warned  map[uint16]bool // Odd addresses already warned about
 }
 
 func (cpu *CPU) Init() {
 	cpu.loadFont()
+	cpu.Pitch = 64
+}
+
+// Reset restarts the loaded ROM: registers, stack, timers, keys and the screen are cleared,
+// the fonts are restored and PC points back at 0x200. The rest of RAM, including anything the
+// ROM wrote, is kept.
+func (cpu *CPU) Reset() {
+	copy(cpu.RAM[cpu.fontBase:], cpu.font)
+	copy(cpu.RAM[cpu.bigFontBase:], cpu.bigFont)
+
+	cpu.V = [16]byte{}
+	cpu.I = 0
+	cpu.PC = 0x200
+	cpu.SP = 0
+	cpu.Stack = [16]uint16{}
+	cpu.DT = 0
+	cpu.ST = 0
+	cpu.Key = [16]bool{}
+	cpu.GFX = [32][64]byte{}
+	cpu.HiGFX = [64][128]byte{}
+	cpu.HiRes = false
+	cpu.DF = true
+	cpu.dirty.markAll()
+	cpu.vblankWait = false
+	cpu.cycles = 0
+}
+
+// CycleCount returns the number of instructions executed since power on or Reset.
+func (cpu *CPU) CycleCount() uint64 {
+	return cpu.cycles
+}
 
-	cpu.keypad = map[sdl.Scancode]byte{
-		sdl.SCANCODE_1: 0x1,
-		sdl.SCANCODE_2: 0x2,
-		sdl.SCANCODE_3: 0x3,
-		sdl.SCANCODE_Q: 0x4,
-		sdl.SCANCODE_W: 0x5,
-		sdl.SCANCODE_E: 0x6,
-		sdl.SCANCODE_A: 0x7,
-		sdl.SCANCODE_S: 0x8,
-		sdl.SCANCODE_D: 0x9,
-		sdl.SCANCODE_X: 0x0,
-		sdl.SCANCODE_Z: 0xA,
-		sdl.SCANCODE_C: 0xB,
-		sdl.SCANCODE_4: 0xC,
-		sdl.SCANCODE_R: 0xD,
-		sdl.SCANCODE_F: 0xE,
-		sdl.SCANCODE_V: 0xF}
+// SetExtendedMemory switches between the classic 4KB address space and XO-CHIP's 64KB, like
+// the ExtendedMemory quirk.
+func (cpu *CPU) SetExtendedMemory(enabled bool) {
+	cpu.Quirks.ExtendedMemory = enabled
+	cpu.I = cpu.addr(cpu.I)
+}
+
+// MemorySize returns the number of addressable bytes of RAM.
+func (cpu *CPU) MemorySize() int {
+	return int(cpu.addrMask()) + 1
+}
+
+func (cpu *CPU) addrMask() uint16 {
+	if cpu.Quirks.ExtendedMemory {
+		return 0xFFFF
+	}
+
+	return 0x0FFF
+}
+
+// addr wraps an address to the active RAM size, so address arithmetic never indexes out of range.
+func (cpu *CPU) addr(a uint16) uint16 {
+	return a & cpu.addrMask()
+}
+
+// Seed replaces the random number source used by instruction Cxkk with a deterministic one.
+func (cpu *CPU) Seed(seed int64) {
+	cpu.rng = rand.New(rand.NewSource(seed))
 }
 
 func (cpu *CPU) loadFont() {
-	fonts := [80]byte{0xF0, 0x90, 0x90, 0x90, 0xF0, // 0
-		0x20, 0x60, 0x20, 0x20, 0x70, // 1
-		0xF0, 0x10, 0xF0, 0x80, 0xF0, // 2
-		0xF0, 0x10, 0xF0, 0x10, 0xF0, // 3
-		0xA0, 0xA0, 0xF0, 0x20, 0x20, // 4
-		0xF0, 0x80, 0xF0, 0x10, 0xF0, // 5
-		0xF0, 0x80, 0xF0, 0x90, 0xF0, // 6
-		0xF0, 0x10, 0x20, 0x40, 0x40, // 7
-		0xF0, 0x90, 0xF0, 0x90, 0xF0, // 8
-		0xF0, 0x90, 0xF0, 0x10, 0xF0, // 9
-		0xF0, 0x90, 0xF0, 0x90, 0x90, // A
-		0xE0, 0x90, 0xE0, 0x90, 0xE0, // B
-		0xF0, 0x80, 0x80, 0x80, 0xF0, // C
-		0xE0, 0x90, 0x90, 0x90, 0xE0, // D
-		0xF0, 0x80, 0xF0, 0x80, 0xF0, // E
-		0xF0, 0x80, 0xF0, 0x80, 0x80} // F
-
-	copy(cpu.RAM[:], fonts[:])
+	cpu.LoadFont(font[:], 0x000)
+	cpu.LoadFont(bigFont[:], 0x050)
+}
+
+// LoadFont copies a font into RAM at base. An 80 byte font replaces the 4x5 hex digits 0 - F
+// used by Fx29, and a 160 byte font the SUPER-CHIP 8x10 digits. The font must fit below the ROM
+// at 0x200.
+func (cpu *CPU) LoadFont(font []byte, base uint16) error {
+	if len(font) != 80 && len(font) != 160 {
+		return fmt.Errorf("load font: expected 80 or 160 bytes, found %d", len(font))
+	}
+
+	if int(base)+len(font) > 0x200 {
+		return fmt.Errorf("load font: %d bytes at %d overlap the ROM at 0x200", len(font), base)
+	}
+
+	copy(cpu.RAM[base:], font)
+
+	if len(font) == 80 {
+		cpu.fontBase = base
+		cpu.font = append([]byte(nil), font...)
+	} else {
+		cpu.bigFontBase = base
+		cpu.bigFont = append([]byte(nil), font...)
+	}
+
+	return nil
 }
 
 func (cpu *CPU) LoadROM(filename *string) error {
-	// Read file into byte array
-	rom, err := ioutil.ReadFile(*filename)
+	file, err := os.Open(*filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return cpu.LoadROMReader(file)
+}
+
+// LoadROMBytes loads rom into RAM at 0x200 like LoadROMReader.
+func (cpu *CPU) LoadROMBytes(rom []byte) error {
+	return cpu.LoadROMReader(bytes.NewReader(rom))
+}
+
+// LoadROMReader loads a ROM from r into RAM at 0x200 and points PC at it.
+// Gzip-compressed ROMs are detected by their magic bytes and decompressed.
+func (cpu *CPU) LoadROMReader(r io.Reader) error {
+	r, err := ungzip(r)
+	if err != nil {
+		return err
+	}
+
+	// Read file into byte array, reading one byte past the space available to detect ROMs that don't fit
+	available := cpu.MemorySize() - 0x200
+
+	rom, err := ioutil.ReadAll(io.LimitReader(r, int64(available)+1))
 	if err != nil {
 		return err
 	}
 
+	if len(rom) > available {
+		return fmt.Errorf("load ROM: more than the %d bytes available", available)
+	}
+
+	if len(rom) == 0 {
+		return fmt.Errorf("load ROM: empty")
+	}
+
 	// Save ROM size
 	cpu.RS = len(rom)
 
@@ -85,38 +248,218 @@ func (cpu *CPU) LoadROM(filename *string) error {
 	cpu.PC = 0x200
 
 	// Copy program byte array into RAM
-	for i, b := range rom {
-		cpu.RAM[cpu.PC+uint16(i)] = b
-	}
+	copy(cpu.RAM[cpu.PC:], rom)
 
 	return nil
 }
 
-// Helpful for debugging
-func (cpu *CPU) printRAM() {
+// ungzip returns a reader of r's contents, decompressed if they're gzipped. Gzip streams are
+// detected by their magic bytes.
+func ungzip(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1F && magic[1] == 0x8B {
+		unzipped, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+
+		return unzipped, nil
+	}
+
+	return buffered, nil
+}
+
+// DumpRAM writes the interpreter area and the loaded ROM to w, ten bytes per line.
+func (cpu *CPU) DumpRAM(w io.Writer) {
 	for i := 0; i < cpu.RS+512; i++ {
 		if (i % 10) == 0 {
-			fmt.Printf("\n%d: %X", i, cpu.RAM[i])
+			fmt.Fprintf(w, "\n%d: %X", i, cpu.RAM[i])
 		} else if cpu.RAM[i]&0xF0 == 0 {
-			fmt.Printf("\t\t%d: 0%X", i, cpu.RAM[i])
+			fmt.Fprintf(w, "\t\t%d: 0%X", i, cpu.RAM[i])
 		} else {
-			fmt.Printf("\t\t%d: %X", i, cpu.RAM[i])
+			fmt.Fprintf(w, "\t\t%d: %X", i, cpu.RAM[i])
 		}
 	}
 
-	fmt.Println()
+	fmt.Fprintln(w)
 }
 
-// Helpful for debugging
-func (cpu *CPU) printRegisters() {
-	fmt.Printf("\nPC: %d     SP: %d     I: %d\n", cpu.PC, cpu.SP, cpu.I)
-	fmt.Printf("Stack: %v\n", cpu.Stack)
+// PeekRAM returns the byte at addr, wrapped to the active RAM size.
+func (cpu *CPU) PeekRAM(addr uint16) byte {
+	return cpu.RAM[cpu.addr(addr)]
+}
+
+// PokeRAM sets the byte at addr, wrapped to the active RAM size. Nothing is protected; the
+// Debugger's PokeRAM guards the interpreter area.
+func (cpu *CPU) PokeRAM(addr uint16, value byte) {
+	cpu.RAM[cpu.addr(addr)] = value
+}
+
+// DumpRAMToFile writes the raw bytes of the addressable RAM to the file at path, for diffing
+// memory between runs.
+func (cpu *CPU) DumpRAMToFile(path string) error {
+	return ioutil.WriteFile(path, cpu.RAM[:cpu.MemorySize()], 0644)
+}
+
+// LoadRAMFromFile restores RAM written by DumpRAMToFile. Registers are left untouched.
+func (cpu *CPU) LoadRAMFromFile(path string) error {
+	ram, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if len(ram) != cpu.MemorySize() {
+		return fmt.Errorf("load RAM: %s is %d bytes, expected %d", path, len(ram), cpu.MemorySize())
+	}
+
+	copy(cpu.RAM[:], ram)
+
+	return nil
+}
+
+// DumpRegisters writes PC, SP, I, the stack and V0 - VF to w.
+func (cpu *CPU) DumpRegisters(w io.Writer) {
+	fmt.Fprintf(w, "\nPC: %d     SP: %d     I: %d\n", cpu.PC, cpu.SP, cpu.I)
+	fmt.Fprintf(w, "Stack: %v\n", cpu.Stack)
 
 	for i := range cpu.V {
-		fmt.Printf("V%X: %x\t", i, cpu.V[i])
+		fmt.Fprintf(w, "V%X: %x\t", i, cpu.V[i])
 	}
 
-	fmt.Println()
+	fmt.Fprintln(w)
+}
+
+// DumpGFX writes the screen to w as rows of '#' (on) and '.' (off). In SUPER-CHIP high
+// resolution mode it writes the 128x64 screen.
+func (cpu *CPU) DumpGFX(w io.Writer) {
+	if cpu.HiRes {
+		io.WriteString(w, hiGFXString(&cpu.HiGFX))
+		return
+	}
+
+	io.WriteString(w, gfxString(&cpu.GFX))
+}
+
+// gfxString renders a frame as rows of '#' (on) and '.' (off) so it can be read at a glance.
+func gfxString(gfx *[32][64]byte) string {
+	return screenString(64, 32, func(x int, y int) byte { return gfx[y][x] })
+}
+
+// hiGFXString renders the SUPER-CHIP high resolution screen like gfxString.
+func hiGFXString(hi *[64][128]byte) string {
+	return screenString(128, 64, func(x int, y int) byte { return hi[y][x] })
+}
+
+// screenString renders a width x height screen, looking up each pixel's value with pixel.
+func screenString(width int, height int, pixel func(x int, y int) byte) string {
+	var buf bytes.Buffer
+
+	for i := 0; i < height; i++ {
+		for j := 0; j < width; j++ {
+			if pixel(j, i) != 0 {
+				buf.WriteByte('#')
+			} else {
+				buf.WriteByte('.')
+			}
+		}
+		buf.WriteByte('\n')
+	}
+
+	return buf.String()
+}
+
+// CallStack returns a copy of the active stack entries, outermost call first.
+// Each entry is the address of a 2nnn call; ret resumes at the instruction after it.
+func (cpu *CPU) CallStack() []uint16 {
+	depth := cpu.StackDepth()
+	stack := make([]uint16, depth)
+	copy(stack, cpu.Stack[:depth])
+
+	return stack
+}
+
+// StackDepth returns the number of subroutines currently being executed.
+func (cpu *CPU) StackDepth() int {
+	if int(cpu.SP) > len(cpu.Stack) {
+		return len(cpu.Stack)
+	}
+
+	return int(cpu.SP)
+}
+
+// push puts addr on top of the stack, failing with ErrStackOverflow when all 16 levels are in use.
+func (cpu *CPU) push(addr uint16) error {
+	// Error before writing if the stack is full. Valid indices are 0 - 15.
+	if cpu.SP >= uint16(len(cpu.Stack)) {
+		return fmt.Errorf("%w: more than %d nested subroutines", ErrStackOverflow, len(cpu.Stack))
+	}
+
+	cpu.Stack[cpu.SP] = addr
+	cpu.SP += 1
+
+	return nil
+}
+
+// pop takes the address off the top of the stack, failing with ErrStackUnderflow when it's empty.
+func (cpu *CPU) pop() (uint16, error) {
+	// SP is unsigned, so check before decrementing
+	if cpu.SP == 0 {
+		return 0, ErrStackUnderflow
+	}
+
+	cpu.SP -= 1
+
+	return cpu.Stack[cpu.SP], nil
+}
+
+// SetTrace sends the instruction trace to w instead of stdout. A nil w restores stdout.
+func (cpu *CPU) SetTrace(w io.Writer) {
+	cpu.trace = w
+}
+
+func (cpu *CPU) traceWriter() io.Writer {
+	if cpu.trace == nil {
+		return os.Stdout
+	}
+
+	return cpu.trace
+}
+
+// SetLogLevel sets how much goes to the trace writer. Nothing is logged by default.
+func (cpu *CPU) SetLogLevel(level LogLevel) {
+	cpu.logLevel = level
+}
+
+// logf writes a message to the trace writer if the log level includes level.
+func (cpu *CPU) logf(level LogLevel, format string, a ...interface{}) {
+	if cpu.logLevel >= level {
+		fmt.Fprintf(cpu.traceWriter(), format, a...)
+	}
+}
+
+func (cpu *CPU) traceln(a ...interface{}) {
+	if cpu.logLevel >= LogTrace {
+		fmt.Fprintln(cpu.traceWriter(), a...)
+	}
+}
+
+func (cpu *CPU) tracef(format string, a ...interface{}) {
+	cpu.logf(LogTrace, format, a...)
+}
+
+// Helpful for debugging
+func (cpu *CPU) printRAM() {
+	if cpu.logLevel >= LogTrace {
+		cpu.DumpRAM(cpu.traceWriter())
+	}
+}
+
+// Helpful for debugging
+func (cpu *CPU) printRegisters() {
+	if cpu.logLevel >= LogTrace {
+		cpu.DumpRegisters(cpu.traceWriter())
+	}
 }
 
 // Each opcode is 2 bytes, but RAM is a byte array, so it must be accessed twice to create the opcode.
@@ -125,14 +468,13 @@ func (cpu *CPU) printRegisters() {
 // RAM[PC + 1] = 0xFE (1 byte)
 // opcode = RAM[PC] + RAM[PC + 1] = 0x01FE
 func (cpu *CPU) getOpCode(PC uint16) uint16 {
-	opCode1 := uint16(cpu.RAM[PC])
-	opCode2 := uint16(cpu.RAM[PC+1])
+	opCode1 := uint16(cpu.RAM[cpu.addr(PC)])
+	opCode2 := uint16(cpu.RAM[cpu.addr(PC+1)]) // The last byte of memory is followed by the first
 	opCode := opCode1<<8 | opCode2
 
-	//fmt.Printf("1st OpCode: %X\t2nd OpCode: %X\t", opCode1, opCode2)
-	if opCode != 0 {
+	if opCode != 0 && cpu.logLevel >= LogTrace {
 		cpu.printRegisters()
-		fmt.Printf("PC: %d\tOpCode: %X\n", cpu.PC, opCode)
+		cpu.tracef("PC: %d\tOpCode: %X\n", cpu.PC, opCode)
 	}
 
 	return opCode
@@ -141,26 +483,40 @@ func (cpu *CPU) getOpCode(PC uint16) uint16 {
 func (cpu *CPU) Cycle() error {
 	// Debug
 	//cpu.printRegisters()
-	if cpu.PC < 4094 {
-		// Get opcode
-		opCode := cpu.getOpCode(cpu.PC)
+	if err := cpu.checkPC(); err != nil {
+		return err
+	}
 
-		// Execute code
-		if err := cpu.execute(opCode); err != nil {
-			return err
-		}
+	// Get opcode
+	opCode := cpu.getOpCode(cpu.PC)
 
-		// Increment PC and decrement DT & ST
+	// Execute code. The timers are counted down at 60Hz by the run loop, not per instruction.
+	if err := cpu.execute(opCode); err != nil {
+		return err
+	}
 
-		if cpu.DT > 0 {
-			cpu.DT -= 1
-		}
+	// Running off the top of memory carries on from address 0
+	cpu.PC = cpu.addr(cpu.PC)
+
+	cpu.cycles++
 
-		if cpu.ST > 0 {
-			cpu.ST -= 1
+	return nil
+}
+
+// RunUntilDraw executes instructions until one sets the draw flag, leaving DF set so the
+// caller can read GFX. It returns immediately if a draw is already pending, and errors if
+// nothing is drawn within maxCycles instructions.
+func (cpu *CPU) RunUntilDraw(maxCycles int) error {
+	for i := 0; i < maxCycles && !cpu.DF; i++ {
+		if err := cpu.Cycle(); err != nil {
+			return err
 		}
 	}
 
+	if !cpu.DF {
+		return fmt.Errorf("run until draw: nothing drawn within %d cycles", maxCycles)
+	}
+
 	return nil
 }
 
@@ -180,9 +536,33 @@ func (cpu *CPU) execute(opCode uint16) error {
 		// Instruction 00EE: Return from a subroutine.
 		return cpu.ret()
 
+	} else if (opCode & 0xFFF0) == 0x00C0 {
+		// Instruction 00Cn: Scroll the screen down n pixels.
+		cpu.scrollDown(n)
+
+	} else if opCode == 0x00FB {
+		// Instruction 00FB: Scroll the screen right 4 pixels.
+		cpu.scrollRight()
+
+	} else if opCode == 0x00FC {
+		// Instruction 00FC: Scroll the screen left 4 pixels.
+		cpu.scrollLeft()
+
+	} else if opCode == 0x00FE {
+		// Instruction 00FE: Switch to the 64x32 low resolution screen.
+		cpu.lowRes()
+
+	} else if opCode == 0x00FF {
+		// Instruction 00FF: Switch to the 128x64 high resolution screen.
+		cpu.highRes()
+
+	} else if (opCode & 0xF000) == 0x0000 {
+		// Instruction 0nnn: Jump to a machine code routine at nnn. Ignored.
+		cpu.sys(nnn)
+
 	} else if (opCode & 0xF000) == 0x1000 {
 		// Instruction 1nnn: Jump to location nnn.
-		return cpu.jump(nnn)
+		cpu.jump(nnn)
 
 	} else if (opCode & 0xF000) == 0x2000 {
 		// Instruction 2nnn: Call subroutine at nnn.
@@ -210,7 +590,6 @@ func (cpu *CPU) execute(opCode uint16) error {
 
 	} else if (opCode & 0xF00F) == 0x8000 {
 		// Instruction 8xy0: Set Vx = Vy.
-		fmt.Printf("UHM 8X000: %X\n", opCode)
 		cpu.loadXY(vx, vy)
 
 	} else if (opCode & 0xF00F) == 0x8001 {
@@ -235,7 +614,7 @@ func (cpu *CPU) execute(opCode uint16) error {
 
 	} else if (opCode & 0xF00F) == 0x8006 {
 		// Instruction 8xy6: Set Vx = Vx SHR 1.
-		cpu.shiftRight(vx)
+		cpu.shiftRight(vx, vy)
 
 	} else if (opCode & 0xF00F) == 0x8007 {
 		// Instruction 8xy7: Set Vx = Vy - Vx, set VF = NOT borrow.
@@ -243,7 +622,7 @@ func (cpu *CPU) execute(opCode uint16) error {
 
 	} else if (opCode & 0xF00F) == 0x800E {
 		// Instruction 8xyE: Set Vx = Vx SHL 1.
-		cpu.shiftLeft(vx)
+		cpu.shiftLeft(vx, vy)
 
 	} else if (opCode & 0xF00F) == 0x9000 {
 		// Instruction 9xy0: Skip next instruction if Vx != Vy.
@@ -255,7 +634,7 @@ func (cpu *CPU) execute(opCode uint16) error {
 
 	} else if (opCode & 0xF000) == 0xB000 {
 		// Instruction Bnnn: Jump to location nnn + V0.
-		cpu.jumpV0(nnn)
+		cpu.jumpV0(vx, nnn)
 
 	} else if (opCode & 0xF000) == 0xC000 {
 		// Instruction Cxkk: Set Vx = random byte AND kk.
@@ -298,6 +677,10 @@ func (cpu *CPU) execute(opCode uint16) error {
 		// Instruction Fx29: Set I = location of sprite for digit Vx.
 		cpu.loadIX(vx)
 
+	} else if (opCode & 0xF0FF) == 0xF030 {
+		// Instruction Fx30: Set I = location of the big sprite for digit Vx.
+		cpu.loadBigIX(vx)
+
 	} else if (opCode & 0xF0FF) == 0xF033 {
 		// Instruction Fx33: Store BCD representation of Vx in memory locations I, I+1, I+2.
 		cpu.loadBCD(vx)
@@ -310,8 +693,24 @@ func (cpu *CPU) execute(opCode uint16) error {
 		// Instruction Fx65: Read registers V0 through Vx in memory starting at location I.
 		cpu.loadV(vx)
 
+	} else if (opCode & 0xF0FF) == 0xF075 {
+		// Instruction Fx75: Store registers V0 through Vx in the RPL user flags, x <= 7.
+		cpu.saveFlags(vx)
+
+	} else if (opCode & 0xF0FF) == 0xF085 {
+		// Instruction Fx85: Read registers V0 through Vx from the RPL user flags, x <= 7.
+		cpu.loadFlags(vx)
+
+	} else if opCode == 0xF002 {
+		// Instruction F002: Load the 16 byte audio pattern from memory starting at location I.
+		cpu.loadPattern()
+
+	} else if (opCode & 0xF0FF) == 0xF03A {
+		// Instruction Fx3A: Set the audio pitch register = Vx.
+		cpu.loadPitch(vx)
+
 	} else {
-		fmt.Printf("Unknown instruction: %X\n", opCode)
+		return fmt.Errorf("%w: %04X at PC %d", ErrUnknownInstruction, opCode, cpu.PC)
 	}
 
 	return nil
@@ -319,13 +718,15 @@ func (cpu *CPU) execute(opCode uint16) error {
 
 // Instruction 00E0: Clear the display.
 func (cpu *CPU) clear() {
-	fmt.Println("Instruction 00E0: Clear the display.")
+	cpu.traceln("Instruction 00E0: Clear the display.")
 
 	// Zero out gfx
 	cpu.GFX = [32][64]byte{}
+	cpu.HiGFX = [64][128]byte{}
 
 	// Set draw flag
 	cpu.DF = true
+	cpu.dirty.markAll()
 
 	// Increment PC counter
 	cpu.PC += 2
@@ -335,54 +736,51 @@ func (cpu *CPU) clear() {
 // The CPU sets the program counter to the address at the top of the stack,
 // then subtracts 1 from the stack pointer.
 func (cpu *CPU) ret() error {
-	fmt.Println("Instruction 00EE: Return from a subroutine.")
+	cpu.traceln("Instruction 00EE: Return from a subroutine.")
 
-	// Decrement stack pointer and error if it's below 0.
-	if cpu.SP -= 1; cpu.SP < 0 {
-		return fmt.Errorf("stack pointer out of bound: %d", cpu.SP)
+	// Error if there's nothing to return to
+	addr, err := cpu.pop()
+	if err != nil {
+		return fmt.Errorf("ret: %w at PC %d", err, cpu.PC)
 	}
 
-	cpu.PC = cpu.Stack[cpu.SP]
-	cpu.PC += 2
+	cpu.PC = addr + 2
 
 	return nil
 }
 
+// Instruction 0nnn: Jump to a machine code routine at nnn.
+// This called native code on the original COSMAC VIP and is ignored by modern interpreters,
+// so it's a no-op that just moves on to the next instruction.
+func (cpu *CPU) sys(nnn uint16) {
+	cpu.tracef("Instruction 0nnn: Ignored machine code routine at %X.\n", nnn)
+
+	cpu.PC += 2
+}
+
 // Instruction 1nnn: Jump to location nnn.
 // The CPU sets the program counter to nnn.
-func (cpu *CPU) jump(nnn uint16) error {
-	fmt.Println("Instruction 1nnn: Jump to location nnn.")
-	//fmt.Printf("nnn: %d\n", nnn)
-
-	// Set PC to nnn. Error if it accesses invalid memory.
-	if cpu.PC = nnn; cpu.PC > 4028 {
-		return fmt.Errorf("jump: program counter out of bound: %d", nnn)
-	}
+func (cpu *CPU) jump(nnn uint16) {
+	cpu.traceln("Instruction 1nnn: Jump to location nnn.")
 
-	//fmt.Printf("New PC: %d\n", cpu.PC)
-	return nil
+	// Set PC to nnn, wrapped to the active RAM size like every other address
+	cpu.PC = cpu.addr(nnn)
 }
 
 // Instruction 2nnn: Call subroutine at nnn.
 // The CPU increments the stack pointer, then puts the current PC on the top of the stack.
 // The PC is then set to nnn.
 func (cpu *CPU) call(nnn uint16) error {
-	fmt.Println("Instruction 2nnn: Call subroutine at nnn.")
-	//fmt.Printf("nnn: %d\n", nnn)
-
-	cpu.Stack[cpu.SP] = cpu.PC
+	cpu.traceln("Instruction 2nnn: Call subroutine at nnn.")
 
-	// Set PC to nnn. Error if it accesses invalid memory.
-	if cpu.PC = nnn; cpu.PC > 4028 {
-		return fmt.Errorf("call: program counter out of bound: %d", nnn)
+	// Push PC, leaving everything as it was if the stack is full
+	if err := cpu.push(cpu.PC); err != nil {
+		return fmt.Errorf("call: %w at PC %d", err, cpu.PC)
 	}
 
-	// Increment stack pointer and error if it's above it's length
-	if cpu.SP += 1; cpu.SP > uint16(len(cpu.Stack)) {
-		return fmt.Errorf("call: stack pointer out of bound: %d", cpu.SP)
-	}
+	// Set PC to nnn, wrapped to the active RAM size
+	cpu.PC = cpu.addr(nnn)
 
-	//fmt.Printf("New Stack: %v\nnew SP: %d\tPC: %d\n", cpu.Stack, cpu.SP, cpu.PC)
 	return nil
 }
 
@@ -390,14 +788,12 @@ func (cpu *CPU) call(nnn uint16) error {
 // The CPU compares register Vx to kk, and if they are equal,
 // increments the program counter by 2.
 func (cpu *CPU) skipIf(vx byte, kk byte) {
-	fmt.Println("Instruction 3xkk: Skip next instruction if Vx == kk.")
-	//fmt.Printf("Vx: %X\tkk: %X\n", vx, kk)
+	cpu.traceln("Instruction 3xkk: Skip next instruction if Vx == kk.")
 
 	if cpu.V[vx] == kk {
 		cpu.PC += 2
 	}
 
-	//fmt.Printf("New PC: %d\n", cpu.PC)
 	cpu.PC += 2
 }
 
@@ -405,14 +801,12 @@ func (cpu *CPU) skipIf(vx byte, kk byte) {
 // The CPU compares register Vx to kk, and if they are not equal,
 // increments the program counter by 2.
 func (cpu *CPU) skipIfNot(vx byte, kk byte) {
-	fmt.Println("Instruction 4xkk: Skip next instruction if Vx != kk.")
-	//fmt.Printf("Vx: %X\tkk: %X\n", vx, kk)
+	cpu.traceln("Instruction 4xkk: Skip next instruction if Vx != kk.")
 
 	if cpu.V[vx] != kk {
 		cpu.PC += 2
 	}
 
-	//fmt.Printf("New PC: %d\n", cpu.PC)
 	cpu.PC += 2
 }
 
@@ -420,50 +814,42 @@ func (cpu *CPU) skipIfNot(vx byte, kk byte) {
 // The CPU compares register Vx to register Vy, and if they are equal,
 // increments the program counter by 2.
 func (cpu *CPU) skipIfXY(vx byte, vy byte) {
-	fmt.Println("Instruction 5xy0: Skip next isntruction if Vx = Vy.")
-	//fmt.Printf("Vx: %X\tVy: %X\n", vx, vy)
+	cpu.traceln("Instruction 5xy0: Skip next isntruction if Vx = Vy.")
 
 	if cpu.V[vx] == cpu.V[vy] {
 		cpu.PC += 2
 	}
 
-	//fmt.Printf("New PC: %d\n", cpu.PC)
 	cpu.PC += 2
 }
 
 // Instruction 6xkk: Set Vx = kk.
 // The CPU puts the value kk into register Vx.
 func (cpu *CPU) load(vx byte, kk byte) {
-	fmt.Println("Instruction 6xkk: Set Vx = kk.")
-	//fmt.Printf("Vx: %X\tkk: %X\n", vx, kk)
+	cpu.traceln("Instruction 6xkk: Set Vx = kk.")
 
 	cpu.V[vx] = kk
 
-	//fmt.Printf("New V%X: %X\n", vx, cpu.V[vx])
 	cpu.PC += 2
 }
 
 // Instruction 7xkk: Set Vx = Vx + kk.
 // Adds the value kk to the value of register Vx, then stores the result in Vx.
 func (cpu *CPU) add(vx byte, kk byte) {
-	fmt.Println("Instruction 7xkk: Set Vx = Vx + kk.")
-	//fmt.Printf("Vx: %X\tkk: %X\n", vx, kk)
+	cpu.traceln("Instruction 7xkk: Set Vx = Vx + kk.")
 
 	cpu.V[vx] += kk
 
-	//fmt.Printf("New V%X: %X\n", vx, cpu.V[vx])
 	cpu.PC += 2
 }
 
 // Instruction 8xy0: Set Vx = Vy.
 // Stores the value of register Vy in register Vx.
 func (cpu *CPU) loadXY(vx byte, vy byte) {
-	fmt.Println("Instruction 8xy0: Set Vx = Vy.")
-	//fmt.Printf("Vx: %X\tVy: %X\n", vx, vy)
+	cpu.traceln("Instruction 8xy0: Set Vx = Vy.")
 
 	cpu.V[vx] = cpu.V[vy]
 
-	//fmt.Printf("New V%X: %X\n", vx, cpu.V[vx])
 	cpu.PC += 2
 }
 
@@ -472,12 +858,11 @@ func (cpu *CPU) loadXY(vx byte, vy byte) {
 // A bitwise OR compares the corresponding bits from two values, and if either bit is 1,
 // then the same bit in the result is also 1. Otherwise, it is 0.
 func (cpu *CPU) orXY(vx byte, vy byte) {
-	fmt.Println("Instruction 8xy1: Set Vx = Vx | Vy.")
-	//fmt.Printf("Vx: %X\tVy: %X\n", vx, vy)
+	cpu.traceln("Instruction 8xy1: Set Vx = Vx | Vy.")
 
 	cpu.V[vx] |= cpu.V[vy]
+	cpu.resetVF()
 
-	//fmt.Printf("New V%X: %X", vx, cpu.V[vx])
 	cpu.PC += 2
 }
 
@@ -486,12 +871,11 @@ func (cpu *CPU) orXY(vx byte, vy byte) {
 // A bitwise AND compares the corresponding bits from two values, and if both bits are 1,
 // then the same bit in the result is also 1. Otherwise, it is 0.
 func (cpu *CPU) andXY(vx byte, vy byte) {
-	fmt.Println("Instruction 8xy2: Set Vx = Vx & Vy.")
-	//fmt.Printf("Vx: %X\tVy: %X\n", vx, vy)
+	cpu.traceln("Instruction 8xy2: Set Vx = Vx & Vy.")
 
 	cpu.V[vx] &= cpu.V[vy]
+	cpu.resetVF()
 
-	//fmt.Printf("New V%X: %X", vx, cpu.V[vx])
 	cpu.PC += 2
 }
 
@@ -501,21 +885,26 @@ func (cpu *CPU) andXY(vx byte, vy byte) {
 // and if the bits are not both the same, then the corresponding bit in the result is set to 1.
 // Otherwise, it is 0.
 func (cpu *CPU) xorXY(vx byte, vy byte) {
-	fmt.Println("Instruction 8xy3: Set Vx = Vx ^ Vy.")
-	//fmt.Printf("Vx: %X\tVy: %X\n", vx, vy)
+	cpu.traceln("Instruction 8xy3: Set Vx = Vx ^ Vy.")
 
 	cpu.V[vx] ^= cpu.V[vy]
+	cpu.resetVF()
 
-	//fmt.Printf("New V%X: %X", vx, cpu.V[vx])
 	cpu.PC += 2
 }
 
+// The original interpreter clobbered VF in the logic instructions 8xy1, 8xy2 and 8xy3.
+func (cpu *CPU) resetVF() {
+	if cpu.Quirks.LogicResetsVF {
+		cpu.V[0xF] = 0
+	}
+}
+
 // Instruction 8xy4: Set Vx = Vx + Vy, set VF = carry.
 // The values of Vx and Vy are added together. If the result is greater than 8 bits (i.e., > 255,)
 // VF is set to 1, otherwise 0. Only the lowest 8 bits of the result are kept, and stored in Vx.
 func (cpu *CPU) addXY(vx byte, vy byte) {
-	fmt.Println("Instruction 8xy4: Set Vx = Vx + Vy, set VF = carry.")
-	//fmt.Printf("Vx: %X\tVy: %X\n", vx, vy)
+	cpu.traceln("Instruction 8xy4: Set Vx = Vx + Vy, set VF = carry.")
 
 	num := uint(cpu.V[vx]) + uint(cpu.V[vy])
 
@@ -527,78 +916,81 @@ func (cpu *CPU) addXY(vx byte, vy byte) {
 
 	cpu.V[vx] = byte(num)
 
-	//fmt.Printf("New V%X: %X", vx, cpu.V[vx])
 	cpu.PC += 2
 }
 
 // Instruction 8xy5: Set Vx = Vx - Vy, set VF = NOT borrow.
-// If Vx > Vy, then VF is set to 1, otherwise 0. Then Vy is subtracted from Vx,
+// If Vx >= Vy, then VF is set to 1, otherwise 0. Then Vy is subtracted from Vx,
 // and the results stored in Vx.
 func (cpu *CPU) subXY(vx byte, vy byte) {
-	fmt.Println("Instruction 8xy5: Set Vx = Vx - Vy, set VF = NOT borrow.")
-	//fmt.Printf("Vx: %X\tVy: %X\n", vx, vy)
+	cpu.traceln("Instruction 8xy5: Set Vx = Vx - Vy, set VF = NOT borrow.")
 
-	if cpu.V[vx] > cpu.V[vy] {
-		cpu.V[0xF] = 1
-	} else {
-		cpu.V[0xF] = 0
+	var notBorrow byte
+	if cpu.V[vx] >= cpu.V[vy] {
+		notBorrow = 1
 	}
 
+	// VF is written last, so 8Fy5 leaves the flag rather than the difference
 	cpu.V[vx] = cpu.V[vx] - cpu.V[vy]
+	cpu.V[0xF] = notBorrow
 
-	//fmt.Printf("New V%X: %X", vx, cpu.V[vx])
 	cpu.PC += 2
 }
 
 // Instruction 8xy6: Set Vx = Vx SHR 1.
 // If the least-significant bit of Vx is 1, then VF is set to 1, otherwise 0.
 // Then Vx is divided by 2.
-func (cpu *CPU) shiftRight(vx byte) {
-	fmt.Println("Instruction 8xy6: Set Vx = Vx SHR 1.")
-	//fmt.Printf("Vx: %X\n", vx)
+func (cpu *CPU) shiftRight(vx byte, vy byte) {
+	cpu.traceln("Instruction 8xy6: Set Vx = Vx SHR 1.")
 
-	cpu.V[0xF] = cpu.V[vx] & 0x1
+	value := cpu.shiftSource(vx, vy)
 
 	// Divide by 2
-	cpu.V[vx] = cpu.V[vx] >> 1
+	cpu.V[vx] = value >> 1
+	cpu.V[0xF] = value & 0x1
 
-	//fmt.Printf("New V%X: %X\tVF: %X", vx, cpu.V[vx], cpu.V[0xF])
 	cpu.PC += 2
 }
 
 // Instruction 8xy7: Set Vx = Vy - Vx, set VF = NOT borrow.
-// If Vy > Vx, then VF is set to 1, otherwise 0. Then Vx is subtracted from Vy,
+// If Vy >= Vx, then VF is set to 1, otherwise 0. Then Vx is subtracted from Vy,
 // and the results stored in Vx.
 func (cpu *CPU) subYX(vx byte, vy byte) {
-	fmt.Println("Instruction 8xy7: Set Vx = Vy - Vx, set VF = NOT borrow.")
-	//fmt.Printf("Vx: %X\tVy: %X\n", vx, vy)
+	cpu.traceln("Instruction 8xy7: Set Vx = Vy - Vx, set VF = NOT borrow.")
 
-	if cpu.V[vy] > cpu.V[vx] {
-		cpu.V[0xF] = 1
-	} else {
-		cpu.V[0xF] = 0
+	var notBorrow byte
+	if cpu.V[vy] >= cpu.V[vx] {
+		notBorrow = 1
 	}
 
+	// VF is written last, so 8Fy7 leaves the flag rather than the difference
 	cpu.V[vx] = cpu.V[vy] - cpu.V[vx]
+	cpu.V[0xF] = notBorrow
 
-	//fmt.Printf("New V%X: %d\tVF: %d\n", vx, cpu.V[vx], cpu.V[0xF])
 	cpu.PC += 2
 }
 
+// The original interpreter shifted Vy into Vx, later ones shift Vx in place.
+func (cpu *CPU) shiftSource(vx byte, vy byte) byte {
+	if cpu.Quirks.ShiftUsesVY {
+		return cpu.V[vy]
+	}
+
+	return cpu.V[vx]
+}
+
 // Instruction 8xyE: Set Vx = Vx SHL 1.
 // If the most-significant bit of Vx is 1, then VF is set to 1, otherwise to 0.
 // Then Vx is multiplied by 2.
-func (cpu *CPU) shiftLeft(vx byte) {
-	fmt.Println("Instruction 8xyE: Set Vx = Vx SHL 1.")
-	//fmt.Printf("VX: %X\n", cpu.V[vx])
+func (cpu *CPU) shiftLeft(vx byte, vy byte) {
+	cpu.traceln("Instruction 8xyE: Set Vx = Vx SHL 1.")
 
-	// Get the most significant bit in a byte
-	cpu.V[0xF] = (cpu.V[vx] >> 7) & 0x1
+	value := cpu.shiftSource(vx, vy)
 
-	// Multiple by 2
-	cpu.V[vx] = cpu.V[vx] << 1
+	// Multiple by 2, then keep the most significant bit that was shifted out
+	cpu.V[vx] = value << 1
+	cpu.V[0xF] = (value >> 7) & 0x1
 
-	//fmt.Printf("New V%X: %d\tVF: %d\n", vx, cpu.V[vx], cpu.V[0xF])
 	cpu.PC += 2
 }
 
@@ -606,38 +998,38 @@ func (cpu *CPU) shiftLeft(vx byte) {
 // The values of Vx and Vy are compared, and if they are not equal,
 // the program counter is increased by 2.
 func (cpu *CPU) skipIfNotXY(vx byte, vy byte) {
-	fmt.Println("Instruction 9xy0: Skip next instruction if Vx != Vy.")
-	//fmt.Printf("Vx: %X\tVy: %X\n", vx, vy)
+	cpu.traceln("Instruction 9xy0: Skip next instruction if Vx != Vy.")
 
 	if cpu.V[vx] != cpu.V[vy] {
 		cpu.PC += 2
 	}
 
-	//fmt.Printf("New PC: %d\n", cpu.PC)
 	cpu.PC += 2
 }
 
 // Instruction Annn: Set I = nnn.
 // The value of register I is set to nnn.
 func (cpu *CPU) loadI(nnn uint16) {
-	fmt.Println("Instruction Annn: Set I = nnn.")
-	//fmt.Printf("nnn: %X\n", nnn)
+	cpu.traceln("Instruction Annn: Set I = nnn.")
 
-	cpu.I = uint(nnn)
+	cpu.I = cpu.addr(nnn)
 
-	//fmt.Printf("New I: %X", cpu.I)
 	cpu.PC += 2
 }
 
 // Instruction Bnnn: Jump to location nnn + V0.
 // The program counter is set to nnn plus the value of V0.
-func (cpu *CPU) jumpV0(nnn uint16) {
-	fmt.Println("Instruction Bnnn: Jump to location nnn + V0.")
-	//fmt.Printf("nnn: %X\n", nnn)
+// With the JumpUsesVX quirk this is Bxnn instead: jump to xnn plus the value of Vx.
+func (cpu *CPU) jumpV0(vx byte, nnn uint16) {
+	cpu.traceln("Instruction Bnnn: Jump to location nnn + V0.")
 
-	cpu.PC = uint16(cpu.V[0x0]) + nnn
+	offset := cpu.V[0x0]
+	if cpu.Quirks.JumpUsesVX {
+		offset = cpu.V[vx]
+	}
 
-	//fmt.Printf("New PC: %d\n", cpu.PC)
+	// The sum can pass the top of classic memory, so wrap it like any other address
+	cpu.PC = cpu.addr(uint16(offset) + nnn)
 }
 
 // Instruction Cxkk: Set Vx = random byte AND kk.
@@ -645,13 +1037,15 @@ func (cpu *CPU) jumpV0(nnn uint16) {
 // which is then ANDed with the value kk. The results are stored in Vx.
 // See instruction 8xy2 for more information on AND.
 func (cpu *CPU) rand(vx byte, kk byte) {
-	fmt.Println("Instruction Cxkk: Set Vx = random byte AND kk.")
-	//fmt.Printf("Vx: %X\n", vx)
+	cpu.traceln("Instruction Cxkk: Set Vx = random byte AND kk.")
+
+	if cpu.rng == nil {
+		cpu.Seed(time.Now().UnixNano())
+	}
 
-	r := byte(rand.Intn(0xFF))
+	r := byte(cpu.rng.Intn(0x100))
 	cpu.V[vx] = kk & r
 
-	//fmt.Printf("New V%X: %X", vx, cpu.V[vx])
 	cpu.PC += 2
 }
 
@@ -663,56 +1057,69 @@ func (cpu *CPU) rand(vx byte, kk byte) {
 // Sprites are XORed onto the existing screen. If this causes any pixels to be erased,
 // VF is set to 1, otherwise it is set to 0. If the sprite is positioned so part of it
 // is outside the coordinates of the display, it wraps around to the opposite side of the screen.
+// With n = 0, SUPER-CHIP draws a 16x16 sprite from 32 bytes, two per row.
 // See instruction 8xy3 for more information on XOR, and section 2.4, Display,
 // for more information on the Chip-8 screen and sprites.
 func (cpu *CPU) draw(vx byte, vy byte, n byte) error {
-	fmt.Println("Instruction Dxyn: Display nbyte sprite starting at memory location I at (Vx, Vy), set Vf = collusion.")
-	//fmt.Printf("Vx: %X\tVy: %X\tn: %X\n", vx, vy, n)
+	cpu.traceln("Instruction Dxyn: Display nbyte sprite starting at memory location I at (Vx, Vy), set Vf = collusion.")
+
+	// The starting position always wraps onto the screen
+	width, height := cpu.screenSize()
+	x := uint(cpu.V[vx]) % width
+	y := uint(cpu.V[vy]) % height
 
-	x := cpu.V[vx]
-	y := cpu.V[vy]
+	cpu.tracef("Coordinates: (%d, %d)\n", x, y)
 
-	fmt.Printf("Coordinates: (%d, %d)\n", x, y)
-	for i := uint(0); i < uint(n); i++ {
-		if (y + byte(i)) > 32 {
-			return fmt.Errorf("draw: Y out of bounds: %d", y+byte(i))
+	// VF only reports a collision in this sprite, not an earlier one
+	cpu.V[0xF] = 0
+
+	// SUPER-CHIP draws a 16x16 sprite for Dxy0, two bytes per row
+	rows, cols := uint(n), uint(8)
+	if n == 0 {
+		rows, cols = 16, 16
+	}
+
+	for i := uint(0); i < rows; i++ {
+		if cpu.Quirks.ClipSprites && y+i >= height {
+			break
 		}
 
-		value := cpu.RAM[cpu.I+i]
+		// Rows that fall off the bottom wrap around to the top
+		row := (y + i) % height
+
+		// Line the row up at the top of 16 bits, however wide it is
+		var value uint16
+		if cols == 16 {
+			value = uint16(cpu.RAM[cpu.addr(cpu.I+uint16(i*2))])<<8 | uint16(cpu.RAM[cpu.addr(cpu.I+uint16(i*2+1))])
+		} else {
+			value = uint16(cpu.RAM[cpu.addr(cpu.I+uint16(i))]) << 8
+		}
 
-		for j := uint(0); j < 8; j++ {
-			//fmt.Printf("%b", )
-			if (y + byte(i)) > 64 {
-				return fmt.Errorf("draw: X out of bounds: %d", x+byte(j))
+		for j := uint(0); j < cols; j++ {
+			if (value & (0x8000 >> j)) == 0 {
+				continue
 			}
 
-			if (value & (0x80>>j)) != 0 {
-				//fmt.Printf("(%d,%d): %b\t", x+byte(j), y+byte(i), 1)
-				//fmt.Printf("%d", 1)
-				if cpu.GFX[y+byte(i)][x+byte(j)] == 1 {
-					cpu.V[0xF] = 1
-				}
+			if cpu.Quirks.ClipSprites && x+j >= width {
+				break
+			}
 
-				cpu.GFX[y+byte(i)][x+byte(j)] ^= 1
-			} else {
-				//fmt.Printf("(%d,%d): %b\t", x+byte(i), y+byte(j), 0)
-				//fmt.Printf(" ")
+			// Columns that fall off the right wrap around to the left
+			col := (x + j) % width
+			pixel := cpu.pixel(col, row)
+
+			// Erasing any lit pixel is a collision, no matter which row it's in
+			if *pixel == 1 {
+				cpu.V[0xF] = 1
 			}
+
+			*pixel ^= 1
+			cpu.dirty.add(int(col), int(row))
 		}
-		//fmt.Println()
-	}
-
-	//fmt.Print(cpu.GFX)
-	//fmt.Println("GFX\n\n\n\n\n")
-	//for i := 0; i < 32; i++ {
-	//	for j := 0; j < 64; j++ {
-	//		fmt.Printf("%d", cpu.GFX[i][j])
-	//	}
-	//	fmt.Println()
-	//}
-	//
-	//fmt.Printf("%b\n", mem)
+	}
+
 	cpu.DF = true
+	cpu.vblankWait = cpu.Quirks.DisplayWait
 	cpu.PC += 2
 
 	return nil
@@ -722,15 +1129,13 @@ func (cpu *CPU) draw(vx byte, vy byte, n byte) error {
 // Checks the keyboard, and if the key corresponding to the value of Vx is currently
 // in the down position, PC is increased by 2.
 func (cpu *CPU) skipIfKey(vx byte) {
-	fmt.Println("Instruction Ex9E: Skip instruction if key with the value of Vx is pressed.")
-	//fmt.Printf("Vx: %X\n", vx)
+	cpu.traceln("Instruction Ex9E: Skip instruction if key with the value of Vx is pressed.")
 
-	// If the key is pressed
-	if cpu.Key[cpu.V[vx]] {
+	// If the key is pressed. Only the low nibble of Vx names a key.
+	if cpu.Key[cpu.V[vx]&0x0F] {
 		cpu.PC += 2
 	}
 
-	//fmt.Printf("New PC: %d\tKey: %d\tPressed: %t\n", cpu.PC, cpu.V[vx], cpu.Key[cpu.V[vx]])
 	cpu.PC += 2
 }
 
@@ -738,85 +1143,81 @@ func (cpu *CPU) skipIfKey(vx byte) {
 // Checks the keyboard, and if the key corresponding to the value of Vx is currently
 // in the up position, PC is increased by 2.
 func (cpu *CPU) skipIfKeyNot(vx byte) {
-	fmt.Println("Instruction ExA1: Skip next instruction if key with the value of Vx is not pressed.")
-	//fmt.Printf("Vx: %X\n", vx)
+	cpu.traceln("Instruction ExA1: Skip next instruction if key with the value of Vx is not pressed.")
 
-	// If the key isn't pressed
-	if !cpu.Key[cpu.V[vx]] {
+	// If the key isn't pressed. Only the low nibble of Vx names a key.
+	if !cpu.Key[cpu.V[vx]&0x0F] {
 		cpu.PC += 2
 	}
 
-	//fmt.Printf("New PC: %d\tKey: %d\tNot Pressed: %t\n", cpu.PC, cpu.V[vx], cpu.Key[cpu.V[vx]])
 	cpu.PC += 2
 }
 
 // Instruction Fx07: Set Vx = delay timer value.
 // The value of DT is placed into Vx.
 func (cpu *CPU) loadXDT(vx byte) {
-	fmt.Println("Instruction Fx07: Set Vx = delay timer value.")
-	//fmt.Printf("Vx: %X\n", vx)
+	cpu.traceln("Instruction Fx07: Set Vx = delay timer value.")
 
 	cpu.V[vx] = cpu.DT
-	//fmt.Printf("New V%X: %X", vx, cpu.V[vx])
 	cpu.PC += 2
 }
 
 // Instruction Fx0A: Wait for a key press, store the value of the key in Vx.
 // All execution stops until a key is pressed, then the value of that key is stored in Vx.
+// Keys only change between frames, so until one is pressed the instruction ends the frame and
+// runs again in the next one.
 func (cpu *CPU) loadKey(vx byte) {
-	fmt.Println("Instruction Fx0A: Wait for a key press, store the value of the key in Vx.")
-	//fmt.Printf("Vx: %X\n", vx)
-
-	wait := true
-
-	for wait {
-		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
-			switch eventType := event.(type) {
-			case *sdl.KeyDownEvent:
-				if _, ok := cpu.keypad[eventType.Keysym.Scancode]; ok {
-					cpu.V[vx] = cpu.keypad[eventType.Keysym.Scancode]
-					wait = false
-				}
-			}
+	cpu.traceln("Instruction Fx0A: Wait for a key press, store the value of the key in Vx.")
+
+	for key, pressed := range cpu.Key {
+		if pressed {
+			cpu.V[vx] = byte(key)
+			cpu.PC += 2
+			return
 		}
 	}
 
-	cpu.PC += 2
+	cpu.vblankWait = true
 }
 
 // Instruction Fx15: Set delay timer = Vx.
 // DT is set equal to the value of Vx.
 func (cpu *CPU) loadDTX(vx byte) {
-	fmt.Println("Instruction Fx15: Set delay timer = Vx.")
-	//fmt.Printf("Vx: %X\n", vx)
+	cpu.traceln("Instruction Fx15: Set delay timer = Vx.")
 
 	cpu.DT = cpu.V[vx]
 
-	//fmt.Printf("New DT: %d", cpu.DT)
 	cpu.PC += 2
 }
 
 // Instruction Fx18: Set sound timer = Vx.
 // ST is set equal to the value of Vx.
 func (cpu *CPU) loadSTX(vx byte) {
-	fmt.Println("Instruction Fx18: Set sounder timer = Vx.")
-	//fmt.Printf("Vx: %X\n", vx)
+	cpu.traceln("Instruction Fx18: Set sounder timer = Vx.")
 
 	cpu.ST = cpu.V[vx]
 
-	//fmt.Printf("New ST: %d", cpu.ST)
 	cpu.PC += 2
 }
 
 // Instruction Fx1E: Set I = I + Vx.
 // The values of I and Vx are added, and the results are stored in I.
 func (cpu *CPU) addIX(vx byte) {
-	fmt.Println("Instruction Fx1E : Set I = I + Vx.")
-	//fmt.Printf("Vx: %X\n", vx)
+	cpu.traceln("Instruction Fx1E : Set I = I + Vx.")
 
-	cpu.I = cpu.I + uint(cpu.V[vx])
+	sum := int(cpu.I) + int(cpu.V[vx])
+
+	// Some interpreters flag overflow past the 12-bit address space in VF
+	if cpu.Quirks.AddIOverflowSetsVF {
+		if sum > 0x0FFF {
+			cpu.V[0xF] = 1
+		} else {
+			cpu.V[0xF] = 0
+		}
+	}
+
+	cpu.I = cpu.addr(uint16(sum))
 
-	//fmt.Printf("New I: %X", cpu.I)
 	cpu.PC += 2
 }
 
@@ -824,12 +1225,20 @@ func (cpu *CPU) addIX(vx byte) {
 // The value of I is set to the location for the hexadecimal sprite corresponding
 // to the value of Vx. See section 2.4, Display, for more information on the Chip-8 hexadecimal font.
 func (cpu *CPU) loadIX(vx byte) {
-	fmt.Println("Instruction Fx29: Set I = location of sprite for digit Vx.")
-	//fmt.Printf("V%X: %X\tI: %X\n", vx, cpu.V[vx], cpu.I)
+	cpu.traceln("Instruction Fx29: Set I = location of sprite for digit Vx.")
+
+	// Only the low nibble selects a digit, so I always points into the font
+	cpu.I = cpu.fontBase + uint16(cpu.V[vx]&0x0F)*5
+
+	cpu.PC += 2
+}
 
-	cpu.I = uint(cpu.V[vx]) * 5
+// Instruction Fx30: Set I = location of the big sprite for digit Vx.
+// SUPER-CHIP's 8x10 font works like Fx29, with 10 bytes per digit.
+func (cpu *CPU) loadBigIX(vx byte) {
+	cpu.traceln("Instruction Fx30: Set I = location of the big sprite for digit Vx.")
 
-	//fmt.Printf("New I: %X\n\n", cpu.I)
+	cpu.I = cpu.bigFontBase + uint16(cpu.V[vx]&0x0F)*10
 	cpu.PC += 2
 }
 
@@ -837,17 +1246,15 @@ func (cpu *CPU) loadIX(vx byte) {
 // The CPU takes the decimal value of Vx, and places the hundreds digit in memory
 // at location in I, the tens digit at location I+1, and the ones digit at location I+2.
 func (cpu *CPU) loadBCD(vx byte) {
-	fmt.Println("Instruction Fx33: Store BCD represention of Vx in memory locations I, I+1, I+2.")
-	//fmt.Printf("Vx: %X\n", vx)
+	cpu.traceln("Instruction Fx33: Store BCD represention of Vx in memory locations I, I+1, I+2.")
 
 	dec := cpu.V[vx]
 
 	for i := 2; i >= 0; i-- {
-		cpu.RAM[cpu.I+uint(i)] = byte(dec % 10)
+		cpu.RAM[cpu.addr(cpu.I+uint16(i))] = byte(dec % 10)
 		dec /= 10
 	}
 
-	//fmt.Printf("Num: %d\tI: %d\tI+1: %d\tI+2: %d\n", cpu.V[vx], cpu.RAM[cpu.I], cpu.RAM[cpu.I+1], cpu.RAM[cpu.I+2])
 	cpu.PC += 2
 }
 
@@ -855,35 +1262,56 @@ func (cpu *CPU) loadBCD(vx byte) {
 // The CPU copies the values of registers V0 through Vx into memory,
 // starting at the address in I.
 func (cpu *CPU) saveV(vx byte) {
-	fmt.Println("Instruction Fx55: Store registers V0 through Vx in memory starting at location I.")
-	//fmt.Printf("Vx: %X\n", vx)
+	cpu.traceln("Instruction Fx55: Store registers V0 through Vx in memory starting at location I.")
 
-	for i := uint(0); i <= uint(vx); i++ {
-		cpu.RAM[cpu.I+i] = cpu.V[i]
+	for i := uint16(0); i <= uint16(vx); i++ {
+		cpu.RAM[cpu.addr(cpu.I+i)] = cpu.V[i]
+	}
+
+	// The original interpreter left I pointing past the last register
+	if cpu.Quirks.LoadStoreIncrementsI {
+		cpu.I = cpu.addr(cpu.I + uint16(vx) + 1)
 	}
 
-	//fmt.Printf("New ")
-	//for i := uint(0); i <= uint(vx); i++ {
-	//fmt.Printf("I+%d: %X", i, cpu.RAM[cpu.I+i])
-	//}
-	//fmt.Println()
 	cpu.PC += 2
 }
 
 // Instruction Fx65: Read registers V0 through Vx from memory starting at location I.
 // The CPU reads values from memory starting at location I into registers V0 through Vx.
 func (cpu *CPU) loadV(vx byte) {
-	fmt.Println("Instruction Fx65: Read registers V0 through Vx in memory starting at location I.")
-	//fmt.Printf("Vx: %X\n", vx)
+	cpu.traceln("Instruction Fx65: Read registers V0 through Vx in memory starting at location I.")
+
+	for i := uint16(0); i <= uint16(vx); i++ {
+		cpu.V[i] = cpu.RAM[cpu.addr(cpu.I+i)]
+	}
+
+	// The original interpreter left I pointing past the last register
+	if cpu.Quirks.LoadStoreIncrementsI {
+		cpu.I = cpu.addr(cpu.I + uint16(vx) + 1)
+	}
+
+	cpu.PC += 2
+}
 
-	for i := uint(0); i <= uint(vx); i++ {
-		cpu.V[i] = cpu.RAM[cpu.I+i]
+// Instruction F002: Load the 16 byte audio pattern from memory starting at location I. (XO-CHIP)
+// Each bit of the pattern is one sample, played most significant bit first,
+// for as long as the sound timer is non-zero.
+func (cpu *CPU) loadPattern() {
+	cpu.traceln("Instruction F002: Load the audio pattern from memory starting at location I.")
+
+	for i := range cpu.Pattern {
+		cpu.Pattern[i] = cpu.RAM[cpu.addr(cpu.I+uint16(i))]
 	}
 
-	//fmt.Printf("New ")
-	//for i := range cpu.V {
-	//	fmt.Printf("V%X: %x\t", i, cpu.V[i])
-	//}
-	//fmt.Println()
+	cpu.patternLoaded = true
+	cpu.PC += 2
+}
+
+// Instruction Fx3A: Set the audio pitch register = Vx. (XO-CHIP)
+// The pattern is played at 4000 * 2^((Vx - 64) / 48) bits per second.
+func (cpu *CPU) loadPitch(vx byte) {
+	cpu.traceln("Instruction Fx3A: Set the audio pitch register = Vx.")
+
+	cpu.Pitch = cpu.V[vx]
 	cpu.PC += 2
 }
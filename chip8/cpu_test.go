@@ -1,9 +1,343 @@
 package CHIP8
 
 import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
+func TestDumpRAM(t *testing.T) {
+	cpu := &CPU{}
+	cpu.RS = 0
+	cpu.RAM[0] = 0xF0
+	cpu.RAM[1] = 0x0A
+
+	var buf bytes.Buffer
+	cpu.DumpRAM(&buf)
+
+	expected := "\n0: F0\t\t1: 0A\t\t2: 00"
+	if !bytes.HasPrefix(buf.Bytes(), []byte(expected)) {
+		t.Errorf("TestDumpRAM: unexpected output. Expected prefix: %q Received: %q", expected, buf.String())
+	}
+
+	// 512 bytes at ten per line, plus the trailing newline
+	if lines := bytes.Count(buf.Bytes(), []byte("\n")); lines != 53 {
+		t.Errorf("TestDumpRAM: unexpected number of lines. Expected: %d Received: %d", 53, lines)
+	}
+}
+
+func TestDumpRegisters(t *testing.T) {
+	cpu := &CPU{}
+	cpu.PC = 0x200
+	cpu.SP = 1
+	cpu.I = 0x30
+	cpu.Stack[0] = 0x20A
+	cpu.V[0x0] = 0xAB
+	cpu.V[0xF] = 1
+
+	var buf bytes.Buffer
+	cpu.DumpRegisters(&buf)
+
+	expected := "\nPC: 512     SP: 1     I: 48\n" +
+		"Stack: [522 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0]\n" +
+		"V0: ab\tV1: 0\tV2: 0\tV3: 0\tV4: 0\tV5: 0\tV6: 0\tV7: 0\t" +
+		"V8: 0\tV9: 0\tVA: 0\tVB: 0\tVC: 0\tVD: 0\tVE: 0\tVF: 1\t\n"
+
+	if out := buf.String(); out != expected {
+		t.Errorf("TestDumpRegisters: unexpected output.\nExpected: %q\nReceived: %q", expected, out)
+	}
+}
+
+func TestLoadROMReader(t *testing.T) {
+	rom := []byte{0x60, 0x05, 0x1F, 0x8B, 0x12, 0x00}
+
+	var zipped bytes.Buffer
+	w := gzip.NewWriter(&zipped)
+	w.Write(rom)
+	w.Close()
+
+	// Gzipped and plain ROMs load the same
+	for name, r := range map[string]*bytes.Reader{
+		"plain":   bytes.NewReader(rom),
+		"gzipped": bytes.NewReader(zipped.Bytes()),
+	} {
+		cpu := &CPU{}
+		if err := cpu.LoadROMReader(r); err != nil {
+			t.Fatalf("TestLoadROMReader: failed to load the %s ROM: %v", name, err)
+		}
+
+		if !bytes.Equal(cpu.RAM[0x200:0x200+len(rom)], rom) || cpu.RS != len(rom) || cpu.PC != 0x200 {
+			t.Errorf("TestLoadROMReader: unexpected RAM for the %s ROM. Expected: %X Received: %X", name, rom, cpu.RAM[0x200:0x200+len(rom)])
+		}
+	}
+
+	if err := (&CPU{}).LoadROMReader(bytes.NewReader([]byte{0x1F, 0x8B, 0x00})); err == nil {
+		t.Errorf("TestLoadROMReader: expected an error for a corrupt gzip stream")
+	}
+
+	// Decompressed size is checked against RAM, however small the compressed ROM is
+	zipped.Reset()
+	w = gzip.NewWriter(&zipped)
+	w.Write(make([]byte, 0x1000))
+	w.Close()
+
+	if err := (&CPU{}).LoadROMReader(&zipped); err == nil {
+		t.Errorf("TestLoadROMReader: expected an error for a ROM too large for RAM")
+	}
+}
+
+func TestLoadROMSize(t *testing.T) {
+	cpu := &CPU{}
+	cpu.Init()
+
+	if err := cpu.LoadROMBytes(make([]byte, 4096-0x200)); err != nil {
+		t.Errorf("TestLoadROMSize: failed to load a ROM filling RAM: %v", err)
+	}
+
+	for name, rom := range map[string][]byte{
+		"oversized": make([]byte, 4096-0x200+1),
+		"empty":     {},
+		"nil":       nil,
+	} {
+		err := cpu.LoadROMBytes(rom)
+		if err == nil {
+			t.Errorf("TestLoadROMSize: expected an error for a %s ROM", name)
+		} else if !strings.HasPrefix(err.Error(), "load ROM: ") {
+			t.Errorf("TestLoadROMSize: undescriptive error for a %s ROM: %v", name, err)
+		}
+	}
+}
+
+func TestCycleCount(t *testing.T) {
+	cpu := &CPU{}
+	cpu.Init()
+	cpu.PC = 0x200
+	copy(cpu.RAM[0x200:], []byte{
+		0x60, 0x05, // 200: V0 = 5
+		0x70, 0x01, // 202: V0 += 1
+		0x12, 0x02, // 204: jump 202
+	})
+
+	for i := uint64(1); i <= 10; i++ {
+		if err := cpu.Cycle(); err != nil {
+			t.Fatal(err)
+		}
+
+		if cpu.CycleCount() != i {
+			t.Errorf("TestCycleCount: unexpected count. Expected: %d Received: %d", i, cpu.CycleCount())
+		}
+	}
+
+	cpu.Reset()
+
+	if cpu.CycleCount() != 0 {
+		t.Errorf("TestCycleCount: failed to reset the count. Expected: %d Received: %d", 0, cpu.CycleCount())
+	}
+
+	if cpu.PC != 0x200 || cpu.V[0x0] != 0 || cpu.RAM[0x200] != 0x60 {
+		t.Errorf("TestCycleCount: unexpected state after reset. PC: %d V0: %d RAM[0x200]: %X", cpu.PC, cpu.V[0x0], cpu.RAM[0x200])
+	}
+}
+
+func TestLoadFont(t *testing.T) {
+	cpu := &CPU{}
+	cpu.Init()
+
+	// Every digit drawn as a solid block, placed where XO-CHIP ROMs commonly expect it
+	custom := bytes.Repeat([]byte{0xF0}, 80)
+	if err := cpu.LoadFont(custom, 0x050); err != nil {
+		t.Fatalf("TestLoadFont: failed to load the font: %v", err)
+	}
+
+	if !bytes.Equal(cpu.RAM[0x050:0x0A0], custom) {
+		t.Errorf("TestLoadFont: font not copied into RAM at 0x050")
+	}
+
+	cpu.V[0x2] = 0x3
+	if err := cpu.execute(0xF229); err != nil || cpu.I != 0x050+3*5 {
+		t.Errorf("TestLoadFont: unexpected sprite address for digit 3. Expected: %d Received: %d Error: %v", 0x050+3*5, cpu.I, err)
+	}
+
+	if err := cpu.LoadFont(custom[:79], 0x050); err == nil {
+		t.Errorf("TestLoadFont: expected an error for a font of the wrong size")
+	}
+
+	if err := cpu.LoadFont(bytes.Repeat([]byte{0xFF}, 160), 0x1A0); err == nil {
+		t.Errorf("TestLoadFont: expected an error for a font overlapping the ROM")
+	}
+
+	if err := cpu.LoadFont(bytes.Repeat([]byte{0xFF}, 160), 0x0A0); err != nil || cpu.bigFontBase != 0x0A0 {
+		t.Errorf("TestLoadFont: failed to load the big font. Base: %d Error: %v", cpu.bigFontBase, err)
+	}
+}
+
+func TestLoadBigIX(t *testing.T) {
+	cpu := &CPU{}
+	cpu.Init()
+
+	if !bytes.Equal(cpu.RAM[0x050:0x0F0], bigFont[:]) {
+		t.Fatalf("TestLoadBigIX: big font not loaded at 0x050")
+	}
+
+	for _, digit := range []byte{0x0, 0x7, 0xF} {
+		cpu.V[0x4] = digit
+		if err := cpu.execute(0xF430); err != nil || cpu.I != 0x050+uint16(digit)*10 {
+			t.Errorf("TestLoadBigIX: unexpected sprite address for digit %X. Expected: %d Received: %d Error: %v", digit, 0x050+uint16(digit)*10, cpu.I, err)
+		}
+	}
+
+	// The 8x10 glyph for 1 is there to draw
+	cpu.V[0x4] = 0x1
+	cpu.execute(0xF430)
+	if expected := []byte{0x18, 0x38, 0x58, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x3C}; !bytes.Equal(cpu.RAM[cpu.I:cpu.I+10], expected) {
+		t.Errorf("TestLoadBigIX: unexpected glyph for 1. Expected: %X Received: %X", expected, cpu.RAM[cpu.I:cpu.I+10])
+	}
+
+	// Only the low nibble selects a digit
+	cpu.V[0x4] = 0x12
+	if cpu.execute(0xF430); cpu.I != 0x050+2*10 {
+		t.Errorf("TestLoadBigIX: failed to mask the digit. Expected: %d Received: %d", 0x050+2*10, cpu.I)
+	}
+}
+
+func TestDumpRAMToFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ram")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "ram.bin")
+
+	cpu := &CPU{}
+	cpu.Init()
+	cpu.RAM[0x200] = 0x12
+	cpu.RAM[0xFFF] = 0x34
+	cpu.V[0x3] = 0x56
+
+	if err := cpu.DumpRAMToFile(path); err != nil {
+		t.Fatalf("TestDumpRAMToFile: failed to dump RAM: %v", err)
+	}
+
+	if info, err := os.Stat(path); err != nil || info.Size() != 4096 {
+		t.Fatalf("TestDumpRAMToFile: unexpected dump size. Expected: %d Received: %v %v", 4096, info, err)
+	}
+
+	restored := &CPU{}
+	if err := restored.LoadRAMFromFile(path); err != nil {
+		t.Fatalf("TestDumpRAMToFile: failed to load RAM: %v", err)
+	}
+
+	if restored.RAM != cpu.RAM {
+		t.Errorf("TestDumpRAMToFile: RAM differs after a round trip")
+	}
+
+	if restored.V[0x3] != 0 {
+		t.Errorf("TestDumpRAMToFile: loading RAM touched the registers")
+	}
+
+	// A 4KB dump doesn't fit the 64KB address space
+	restored.SetExtendedMemory(true)
+	if err := restored.LoadRAMFromFile(path); err == nil {
+		t.Errorf("TestDumpRAMToFile: expected an error for a dump of the wrong size")
+	}
+}
+
+func TestDumpGFX(t *testing.T) {
+	cpu := &CPU{}
+	cpu.GFX[0][0] = 1
+	cpu.GFX[0][63] = 1
+	cpu.GFX[1][1] = 1
+	cpu.GFX[31][2] = 1
+
+	var buf bytes.Buffer
+	cpu.DumpGFX(&buf)
+
+	rows := strings.Split(buf.String(), "\n")
+	if len(rows) != 33 || rows[32] != "" {
+		t.Fatalf("TestDumpGFX: unexpected number of rows. Expected: %d Received: %d", 32, len(rows)-1)
+	}
+
+	expected := map[int]string{
+		0:  "#" + strings.Repeat(".", 62) + "#",
+		1:  ".#" + strings.Repeat(".", 62),
+		2:  strings.Repeat(".", 64),
+		31: "..#" + strings.Repeat(".", 61),
+	}
+
+	for i, row := range expected {
+		if rows[i] != row {
+			t.Errorf("TestDumpGFX: unexpected row %d.\nExpected: %s\nReceived: %s", i, row, rows[i])
+		}
+	}
+
+	// High resolution mode dumps the 128x64 screen
+	cpu.HiRes = true
+	cpu.HiGFX[0][127] = 1
+	cpu.HiGFX[63][0] = 1
+
+	buf.Reset()
+	cpu.DumpGFX(&buf)
+
+	rows = strings.Split(buf.String(), "\n")
+	if len(rows) != 65 || rows[64] != "" {
+		t.Fatalf("TestDumpGFX: unexpected number of high resolution rows. Expected: %d Received: %d", 64, len(rows)-1)
+	}
+
+	expected = map[int]string{
+		0:  strings.Repeat(".", 127) + "#",
+		1:  strings.Repeat(".", 128),
+		63: "#" + strings.Repeat(".", 127),
+	}
+
+	for i, row := range expected {
+		if rows[i] != row {
+			t.Errorf("TestDumpGFX: unexpected high resolution row %d.\nExpected: %s\nReceived: %s", i, row, rows[i])
+		}
+	}
+}
+
+func TestRunUntilDraw(t *testing.T) {
+	cpu := &CPU{}
+	cpu.Init()
+	cpu.PC = 0x200
+	copy(cpu.RAM[0x200:], []byte{
+		0x60, 0x08, // 200: V0 = 8
+		0x61, 0x04, // 202: V1 = 4
+		0x62, 0x0A, // 204: V2 = A
+		0xF2, 0x29, // 206: I = sprite for V2
+		0xD0, 0x15, // 208: draw 5 rows at (V0, V1)
+		0x12, 0x0A, // 20A: jump 20A
+	})
+
+	if err := cpu.RunUntilDraw(100); err != nil {
+		t.Fatalf("TestRunUntilDraw: %v", err)
+	}
+
+	if !cpu.DF || cpu.PC != 0x20A {
+		t.Errorf("TestRunUntilDraw: failed to stop at the first draw. DF: %t PC: %d", cpu.DF, cpu.PC)
+	}
+
+	// The font sprite for A: ####, #..#, ####, #..#, #..#
+	assertPixels(t, "TestRunUntilDraw", cpu, [][2]int{
+		{8, 4}, {9, 4}, {10, 4}, {11, 4},
+		{8, 5}, {11, 5},
+		{8, 6}, {9, 6}, {10, 6}, {11, 6},
+		{8, 7}, {11, 7},
+		{8, 8}, {11, 8}})
+
+	// Nothing else is drawn by the spin loop
+	cpu.DF = false
+	if err := cpu.RunUntilDraw(20); err == nil {
+		t.Errorf("TestRunUntilDraw: expected an error when nothing is drawn")
+	}
+}
+
 // Instruction 00E0: Clear the display.
 func TestClear(t *testing.T) {
 
@@ -30,6 +364,63 @@ func TestRet(t *testing.T) {
 		t.Errorf("TestRet: failed to decrement SP after popping the stack. Expected: %d Received: %d", 0, cpu.SP)
 	}
 
+	// Returning with an empty stack is an error and leaves PC alone
+	if err := cpu.ret(); !errors.Is(err, ErrStackUnderflow) {
+		t.Errorf("TestRet: unexpected error on an empty stack. Expected: %v Received: %v", ErrStackUnderflow, err)
+	}
+
+	if cpu.PC != 514 || cpu.SP != 0 {
+		t.Errorf("TestRet: underflow changed the CPU. Expected PC: %d SP: %d Received PC: %d SP: %d", 514, 0, cpu.PC, cpu.SP)
+	}
+}
+
+func TestPushPop(t *testing.T) {
+	cpu := &CPU{}
+
+	if _, err := cpu.pop(); !errors.Is(err, ErrStackUnderflow) {
+		t.Errorf("TestPushPop: unexpected error popping an empty stack. Expected: %v Received: %v", ErrStackUnderflow, err)
+	}
+
+	for i := 0; i < 16; i++ {
+		if err := cpu.push(uint16(0x200 + i)); err != nil {
+			t.Fatalf("TestPushPop: push %d failed: %v", i+1, err)
+		}
+	}
+
+	if depth := cpu.StackDepth(); depth != 16 {
+		t.Errorf("TestPushPop: unexpected depth. Expected: %d Received: %d", 16, depth)
+	}
+
+	if err := cpu.push(0x300); !errors.Is(err, ErrStackOverflow) {
+		t.Errorf("TestPushPop: unexpected error on the 17th push. Expected: %v Received: %v", ErrStackOverflow, err)
+	}
+
+	// Addresses come back last in, first out
+	for i := 15; i >= 0; i-- {
+		addr, err := cpu.pop()
+		if err != nil || addr != uint16(0x200+i) {
+			t.Errorf("TestPushPop: unexpected pop. Expected: %d Received: %d (%v)", 0x200+i, addr, err)
+		}
+	}
+
+	if cpu.SP != 0 {
+		t.Errorf("TestPushPop: failed to empty the stack. Expected SP: %d Received: %d", 0, cpu.SP)
+	}
+}
+
+// Instruction 0nnn: Jump to a machine code routine at nnn.
+// Modern interpreters ignore it.
+func TestSys(t *testing.T) {
+	cpu := &CPU{}
+	cpu.PC = 0x200
+
+	if err := cpu.execute(0x0123); err != nil {
+		t.Errorf("TestSys: unexpected error: %v", err)
+	}
+
+	if cpu.PC != 0x202 {
+		t.Errorf("TestSys: failed to skip the instruction. Expected: %d Received: %d", 0x202, cpu.PC)
+	}
 }
 
 // Instruction 1nnn: Jump to location nnn.
@@ -43,9 +434,90 @@ func TestJump(t *testing.T) {
 	}
 }
 
+func TestJumpTopOfMemory(t *testing.T) {
+	cpu := &CPU{}
+
+	// The last instruction in classic memory is a valid target
+	if cpu.jump(0xFFE); cpu.PC != 0xFFE {
+		t.Errorf("TestJumpTopOfMemory: failed to jump to the top of memory. Expected: %d Received: %d", 0xFFE, cpu.PC)
+	}
+
+	if err := cpu.execute(0x1FFE); err != nil || cpu.PC != 0xFFE {
+		t.Errorf("TestJumpTopOfMemory: 1FFE failed. Expected PC: %d Received: %d Error: %v", 0xFFE, cpu.PC, err)
+	}
+
+	// Bnnn past the top of classic memory wraps around
+	cpu.V[0x0] = 0xFF
+	if cpu.jumpV0(0x0, 0xFFF); cpu.PC != 0x0FE {
+		t.Errorf("TestJumpTopOfMemory: failed to wrap Bnnn. Expected: %d Received: %d", 0x0FE, cpu.PC)
+	}
+
+	// XO-CHIP can address past 0x1000
+	cpu.SetExtendedMemory(true)
+	if cpu.jumpV0(0x0, 0xFFF); cpu.PC != 0x10FE {
+		t.Errorf("TestJumpTopOfMemory: failed to jump above 0x1000. Expected: %d Received: %d", 0x10FE, cpu.PC)
+	}
+}
+
+func TestCycleTopOfMemory(t *testing.T) {
+	cases := []struct {
+		name     string
+		extended bool
+		pc       uint16
+		next     uint16
+	}{
+		{"classic", false, 0xFFE, 0x000},
+		{"extended", true, 0x1200, 0x1202},
+		{"extended top", true, 0xFFFE, 0x0000},
+	}
+
+	for _, c := range cases {
+		cpu := &CPU{}
+		cpu.Init()
+		cpu.SetExtendedMemory(c.extended)
+		cpu.PC = c.pc
+		cpu.RAM[c.pc] = 0x60 // V0 = 0x2A
+		cpu.RAM[c.pc+1] = 0x2A
+
+		if err := cpu.Cycle(); err != nil {
+			t.Fatalf("TestCycleTopOfMemory: %s: cycle failed: %v", c.name, err)
+		}
+
+		if cpu.V[0x0] != 0x2A || cpu.CycleCount() != 1 || cpu.PC != c.next {
+			t.Errorf("TestCycleTopOfMemory: %s: failed to execute at %X. V0: %X Cycles: %d PC: %X", c.name, c.pc, cpu.V[0x0], cpu.CycleCount(), cpu.PC)
+		}
+	}
+
+	// An opcode split across the end of classic memory wraps around to address 0
+	cpu := &CPU{}
+	cpu.Init()
+	cpu.PC = 0xFFF
+	cpu.RAM[0xFFF] = 0x61
+	cpu.RAM[0x000] = 0x07
+
+	if err := cpu.Cycle(); err != nil || cpu.V[0x1] != 0x07 || cpu.PC != 0x001 {
+		t.Errorf("TestCycleTopOfMemory: failed to wrap the opcode at %X. V1: %X PC: %X Error: %v", 0xFFF, cpu.V[0x1], cpu.PC, err)
+	}
+}
+
 // Instruction 2nnn: Call subroutine at nnn.
 // The CPU increments the stack pointer, then puts the current PC on the top of the stack.
 // The PC is then set to nnn.
+func TestRetFreshCPU(t *testing.T) {
+	cpu := &CPU{}
+	cpu.Init()
+	cpu.PC = 0x200
+
+	err := cpu.ret()
+	if !errors.Is(err, ErrStackUnderflow) {
+		t.Fatalf("TestRetFreshCPU: unexpected error. Expected: %v Received: %v", ErrStackUnderflow, err)
+	}
+
+	if expected := "ret: stack underflow at PC 512"; err.Error() != expected {
+		t.Errorf("TestRetFreshCPU: unexpected message. Expected: %q Received: %q", expected, err.Error())
+	}
+}
+
 func TestCall(t *testing.T) {
 	cpu := &CPU{}
 	cpu.PC = 512
@@ -65,6 +537,62 @@ func TestCall(t *testing.T) {
 	}
 }
 
+func TestCallStack(t *testing.T) {
+	cpu := &CPU{}
+	cpu.PC = 0x200
+
+	if depth := cpu.StackDepth(); depth != 0 {
+		t.Errorf("TestCallStack: unexpected depth on a fresh CPU. Expected: %d Received: %d", 0, depth)
+	}
+
+	cpu.call(0x300)
+	cpu.call(0x400)
+
+	stack := cpu.CallStack()
+	if len(stack) != 2 || cpu.StackDepth() != 2 {
+		t.Fatalf("TestCallStack: unexpected depth. Expected: %d Received: %d (%d)", 2, len(stack), cpu.StackDepth())
+	}
+
+	if stack[0] != 0x200 || stack[1] != 0x300 {
+		t.Errorf("TestCallStack: unexpected call stack. Expected: [%d %d] Received: %v", 0x200, 0x300, stack)
+	}
+
+	// The returned slice is a copy
+	stack[0] = 0
+	if cpu.Stack[0] != 0x200 {
+		t.Errorf("TestCallStack: modifying the returned slice changed the stack")
+	}
+}
+
+func TestCallStackOverflow(t *testing.T) {
+	cpu := &CPU{}
+	cpu.PC = 512
+
+	// The stack holds exactly 16 return addresses
+	for i := 0; i < 16; i++ {
+		if err := cpu.call(uint16(0x300 + i*2)); err != nil {
+			t.Fatalf("TestCallStackOverflow: call %d failed: %v", i+1, err)
+		}
+	}
+
+	err := cpu.call(0x400)
+	if err == nil {
+		t.Fatalf("TestCallStackOverflow: expected an error on the 17th nested call")
+	}
+
+	if !errors.Is(err, ErrStackOverflow) {
+		t.Errorf("TestCallStackOverflow: unexpected error. Expected: %v Received: %v", ErrStackOverflow, err)
+	}
+
+	if cpu.SP != 16 {
+		t.Errorf("TestCallStackOverflow: SP changed on overflow. Expected: %d Received: %d", 16, cpu.SP)
+	}
+
+	if cpu.PC != 0x31E {
+		t.Errorf("TestCallStackOverflow: PC changed on overflow. Expected: %d Received: %d", 0x31E, cpu.PC)
+	}
+}
+
 // Instruction 3xkk: Skip next instruction if Vx = kk.
 // The CPU compares register Vx to kk, and if they are equal,
 // increments the program counter by 2.
@@ -244,6 +772,19 @@ func TestSubXY(t *testing.T) {
 	} else if cpu.V[0xF] != 1 {
 		t.Errorf("TestAddXY: failed to set the VF flag correctly. Expected: %d Result: %d", 1, cpu.V[0xF])
 	}
+
+	// Equal operands don't borrow
+	cpu.V[0x0] = 7
+	if cpu.subXY(0x0, 0xE); cpu.V[0x0] != 0 || cpu.V[0xF] != 1 {
+		t.Errorf("TestSubXY: failed to subtract equal operands. Expected: %d VF: %d Result: %d VF: %d", 0, 1, cpu.V[0x0], cpu.V[0xF])
+	}
+
+	// The flag wins when VF is the destination
+	cpu.V[0xF] = 3
+	cpu.V[0x1] = 5
+	if cpu.subXY(0xF, 0x1); cpu.V[0xF] != 0 {
+		t.Errorf("TestSubXY: failed to keep the flag in VF. Expected: %d Result: %d", 0, cpu.V[0xF])
+	}
 }
 
 // Instruction 8xy6: Set Vx = Vx SHR 1.
@@ -253,7 +794,7 @@ func TestShiftRight(t *testing.T) {
 	cpu := &CPU{}
 	cpu.V[0x0] = 0x04
 
-	if cpu.shiftRight(0x0); cpu.V[0x0] != 2 {
+	if cpu.shiftRight(0x0, 0x1); cpu.V[0x0] != 2 {
 		t.Errorf("TestShiftRight: failed to shift right on V%X. Expected: %d Result: %d", 0x0, 2, cpu.V[0x0])
 	} else if cpu.V[0xF] != 0 {
 		t.Errorf("TestShiftRight: failed to set the VF flag correctly. Expected: %d Result: %d", 0, cpu.V[0xF])
@@ -261,7 +802,7 @@ func TestShiftRight(t *testing.T) {
 
 
 	cpu.V[0x0] = 0x5
-	if cpu.shiftRight(0x0); cpu.V[0x0] != 2 {
+	if cpu.shiftRight(0x0, 0x1); cpu.V[0x0] != 2 {
 		t.Errorf("TestShiftRight: failed to shift right on V%X. Expected: %d Result: %d", 0x0, 2, cpu.V[0x0])
 	} else if cpu.V[0xF] != 1 {
 		t.Errorf("TestShiftRight: failed to set the VF flag correctly. Expected: %d Result: %d", 1, cpu.V[0xF])
@@ -292,6 +833,19 @@ func TestSubYX(t *testing.T) {
 	} else if cpu.V[0xF] != 0 {
 		t.Errorf("TestsubYX: failed to set the VF flag correctly. Expected: %d Result %d", 0, cpu.V[0xF])
 	}
+
+	// Equal operands don't borrow
+	cpu.V[0x0] = 7
+	if cpu.subYX(0x0, 0xE); cpu.V[0x0] != 0 || cpu.V[0xF] != 1 {
+		t.Errorf("TestSubYX: failed to subtract equal operands. Expected: %d VF: %d Result: %d VF: %d", 0, 1, cpu.V[0x0], cpu.V[0xF])
+	}
+
+	// The flag wins when VF is the destination
+	cpu.V[0xF] = 3
+	cpu.V[0x1] = 5
+	if cpu.subYX(0xF, 0x1); cpu.V[0xF] != 1 {
+		t.Errorf("TestSubYX: failed to keep the flag in VF. Expected: %d Result: %d", 1, cpu.V[0xF])
+	}
 }
 
 // Instruction 8xyE: Set Vx = Vx SHL 1.
@@ -301,7 +855,7 @@ func TestShiftLeft(t *testing.T) {
 	cpu := &CPU{}
 	cpu.V[0x0] = 128
 
-	if cpu.shiftLeft(0x0); cpu.V[0x0] != 0 {
+	if cpu.shiftLeft(0x0, 0x1); cpu.V[0x0] != 0 {
 		t.Errorf("TestShiftLeft: failed to shift left on V%X. Expected: %d Result: %d", 0x0, 0, cpu.V[0x0])
 	} else if cpu.V[0xF] != 1 {
 		t.Errorf("TestShiftLeft: failed to set the VF flag correctly. Expected: %d Result %d", 1, cpu.V[0xf])
@@ -345,11 +899,51 @@ func TestJumpV0(t *testing.T) {
 	cpu := &CPU{}
 	cpu.V[0x0] = 6
 
-	if cpu.jumpV0(8); cpu.PC != 14 {
+	if cpu.jumpV0(0x0, 8); cpu.PC != 14 {
 		t.Errorf("TestJumpV0: failed to jump nnn times plus V0. Expected: %d Result %d", 14, cpu.PC)
 	}
 }
 
+// With the JumpUsesVX quirk, SUPER-CHIP's Bxnn jumps to xnn plus Vx instead.
+func TestJumpV0Quirk(t *testing.T) {
+	cpu := &CPU{}
+	cpu.V[0x0] = 0x10
+	cpu.V[0x3] = 0x20
+
+	// Classic Bnnn ignores V3
+	if err := cpu.execute(0xB345); err != nil || cpu.PC != 0x355 {
+		t.Errorf("TestJumpV0Quirk: unexpected Bnnn target. Expected: %d Result %d Error: %v", 0x355, cpu.PC, err)
+	}
+
+	cpu.Quirks.JumpUsesVX = true
+	if err := cpu.execute(0xB345); err != nil || cpu.PC != 0x365 {
+		t.Errorf("TestJumpV0Quirk: unexpected Bxnn target. Expected: %d Result %d Error: %v", 0x365, cpu.PC, err)
+	}
+
+	// B0nn reads V0 either way
+	if err := cpu.execute(0xB045); err != nil || cpu.PC != 0x055 {
+		t.Errorf("TestJumpV0Quirk: unexpected B0nn target. Expected: %d Result %d Error: %v", 0x055, cpu.PC, err)
+	}
+}
+
+// Every byte from 0x00 to 0xFF can come out of Cxkk.
+func TestRand(t *testing.T) {
+	cpu := &CPU{}
+	cpu.Seed(1)
+
+	var seen [256]bool
+	for i := 0; i < 10000; i++ {
+		cpu.rand(0x0, 0xFF)
+		seen[cpu.V[0x0]] = true
+	}
+
+	for r, ok := range seen {
+		if !ok {
+			t.Errorf("TestRand: failed to produce every byte. Missing: %X", r)
+		}
+	}
+}
+
 // Instruction Dxyn: Display n-byte sprite starting at memory location I at (Vx, Vy),
 // set VF = collision.
 //
@@ -361,7 +955,225 @@ func TestJumpV0(t *testing.T) {
 // See instruction 8xy3 for more information on XOR, and section 2.4, Display,
 // for more information on the Chip-8 screen and sprites.
 func TestDraw(t *testing.T) {
+	cpu := &CPU{}
+	cpu.I = 0x300
+	cpu.RAM[0x300] = 0xF0 // ####....
+	cpu.RAM[0x301] = 0x90 // #..#....
+
+	// Draw the sprite on a blank screen
+	cpu.V[0x0] = 0
+	cpu.V[0x1] = 0
+
+	if cpu.draw(0x0, 0x1, 2); cpu.V[0xF] != 0 {
+		t.Errorf("TestDraw: set VF without a collision. Expected: %d Result: %d", 0, cpu.V[0xF])
+	}
+
+	assertPixels(t, "TestDraw", cpu, [][2]int{{0, 0}, {1, 0}, {2, 0}, {3, 0}, {0, 1}, {3, 1}})
 
+	if !cpu.DF {
+		t.Errorf("TestDraw: failed to set the draw flag")
+	}
+
+	// Draw it again shifted to (2, 1), so only (3, 1) overlaps and gets erased
+	cpu.V[0x0] = 2
+	cpu.V[0x1] = 1
+
+	if cpu.draw(0x0, 0x1, 2); cpu.V[0xF] != 1 {
+		t.Errorf("TestDraw: failed to set VF on a collision. Expected: %d Result: %d", 1, cpu.V[0xF])
+	}
+
+	assertPixels(t, "TestDraw", cpu, [][2]int{
+		{0, 0}, {1, 0}, {2, 0}, {3, 0},
+		{0, 1}, {2, 1}, {4, 1}, {5, 1},
+		{2, 2}, {5, 2}})
+
+	// A collision in a later row must be detected too
+	cpu = &CPU{}
+	cpu.I = 0x300
+	cpu.RAM[0x300] = 0x00
+	cpu.RAM[0x301] = 0x80
+	cpu.GFX[5][0] = 1
+
+	if cpu.draw(0x0, 0x1, 2); cpu.V[0xF] != 0 {
+		t.Errorf("TestDraw: set VF without a collision. Expected: %d Result: %d", 0, cpu.V[0xF])
+	}
+
+	cpu.V[0x1] = 4
+	if cpu.draw(0x0, 0x1, 2); cpu.V[0xF] != 1 {
+		t.Errorf("TestDraw: failed to set VF on a collision in the second row. Expected: %d Result: %d", 1, cpu.V[0xF])
+	}
+
+	// A sprite that collides with nothing clears VF left set by the previous draw
+	cpu.V[0x0] = 40
+	if cpu.draw(0x0, 0x1, 2); cpu.V[0xF] != 0 {
+		t.Errorf("TestDraw: left VF set after a draw without a collision. Expected: %d Result: %d", 0, cpu.V[0xF])
+	}
+
+	// Sprites wrap around the right and bottom edges
+	cpu = &CPU{}
+	cpu.I = 0x300
+	cpu.RAM[0x300] = 0xF0
+	cpu.RAM[0x301] = 0xF0
+	cpu.V[0x0] = 62
+	cpu.V[0x1] = 31
+
+	if err := cpu.draw(0x0, 0x1, 2); err != nil {
+		t.Fatalf("TestDraw: failed to draw a wrapping sprite: %v", err)
+	}
+
+	assertPixels(t, "TestDraw", cpu, [][2]int{
+		{62, 31}, {63, 31}, {0, 31}, {1, 31},
+		{62, 0}, {63, 0}, {0, 0}, {1, 0}})
+
+	// A 4x4 square at (62, 30) wraps into columns 0 - 1 and rows 0 - 1
+	cpu = &CPU{}
+	cpu.I = 0x300
+	copy(cpu.RAM[0x300:], []byte{0xF0, 0xF0, 0xF0, 0xF0})
+	cpu.V[0x0] = 62
+	cpu.V[0x1] = 30
+
+	if err := cpu.draw(0x0, 0x1, 4); err != nil {
+		t.Fatalf("TestDraw: failed to draw a wrapping sprite: %v", err)
+	}
+
+	var lit [][2]int
+	for _, y := range []int{30, 31, 0, 1} {
+		for _, x := range []int{62, 63, 0, 1} {
+			lit = append(lit, [2]int{x, y})
+		}
+	}
+
+	assertPixels(t, "TestDraw", cpu, lit)
+}
+
+func TestDrawCorner(t *testing.T) {
+	// A 3 row sprite at (63, 30) straddles the bottom-right corner:
+	//   ##......
+	//   #.......
+	//   .#......
+	tests := []struct {
+		name   string
+		quirks Quirks
+		lit    [][2]int
+	}{
+		{"wrap", Quirks{}, [][2]int{{63, 30}, {0, 30}, {63, 31}, {0, 0}}},
+		{"clip", Quirks{ClipSprites: true}, [][2]int{{63, 30}, {63, 31}}},
+	}
+
+	for _, test := range tests {
+		cpu := &CPU{Quirks: test.quirks}
+		cpu.I = 0x300
+		copy(cpu.RAM[0x300:], []byte{0xC0, 0x80, 0x40})
+		cpu.V[0x0] = 63
+		cpu.V[0x1] = 30
+
+		if err := cpu.draw(0x0, 0x1, 3); err != nil {
+			t.Fatalf("TestDrawCorner: %s: failed to draw: %v", test.name, err)
+		}
+
+		assertPixels(t, "TestDrawCorner: "+test.name, cpu, test.lit)
+
+		// Drawing it again erases every cell it lit
+		if cpu.draw(0x0, 0x1, 3); cpu.V[0xF] != 1 {
+			t.Errorf("TestDrawCorner: %s: failed to set VF redrawing the sprite. Expected: %d Result: %d", test.name, 1, cpu.V[0xF])
+		}
+
+		assertPixels(t, "TestDrawCorner: "+test.name, cpu, nil)
+	}
+
+	// Clipped pixels don't touch the opposite edge, so they can't collide there
+	cpu := &CPU{Quirks: Quirks{ClipSprites: true}}
+	cpu.I = 0x300
+	copy(cpu.RAM[0x300:], []byte{0xC0, 0x80, 0x40})
+	cpu.V[0x0] = 63
+	cpu.V[0x1] = 30
+	cpu.GFX[30][0] = 1
+	cpu.GFX[0][0] = 1
+
+	if cpu.draw(0x0, 0x1, 3); cpu.V[0xF] != 0 {
+		t.Errorf("TestDrawCorner: clip: set VF for a clipped pixel. Expected: %d Result: %d", 0, cpu.V[0xF])
+	}
+
+	assertPixels(t, "TestDrawCorner: clip", cpu, [][2]int{{63, 30}, {63, 31}, {0, 30}, {0, 0}})
+}
+
+func TestDrawRightEdge(t *testing.T) {
+	// An 8 pixel wide row at x = 60 runs 4 columns past the right edge. Rows index GFX by y and
+	// columns by x, so it must stay in row 3 whether it wraps or clips.
+	tests := []struct {
+		name   string
+		quirks Quirks
+		lit    [][2]int
+	}{
+		{"wrap", Quirks{}, [][2]int{{60, 3}, {61, 3}, {62, 3}, {63, 3}, {0, 3}, {1, 3}, {2, 3}, {3, 3}}},
+		{"clip", Quirks{ClipSprites: true}, [][2]int{{60, 3}, {61, 3}, {62, 3}, {63, 3}}},
+	}
+
+	for _, test := range tests {
+		cpu := &CPU{Quirks: test.quirks}
+		cpu.I = 0x300
+		cpu.RAM[0x300] = 0xFF
+		cpu.V[0x0] = 60
+		cpu.V[0x1] = 3
+
+		if err := cpu.draw(0x0, 0x1, 1); err != nil {
+			t.Fatalf("TestDrawRightEdge: %s: failed to draw: %v", test.name, err)
+		}
+
+		assertPixels(t, "TestDrawRightEdge: "+test.name, cpu, test.lit)
+	}
+}
+
+func TestDraw16x16(t *testing.T) {
+	cpu := &CPU{}
+	cpu.I = 0x300
+	cpu.V[0x0] = 10
+	cpu.V[0x1] = 5
+
+	// A full top row, then a diagonal from the top left to the bottom right corner
+	rom := make([]byte, 32)
+	rom[0], rom[1] = 0xFF, 0xFF
+	for i := 1; i < 16; i++ {
+		rom[i*2+i/8] = 0x80 >> uint(i%8)
+	}
+	copy(cpu.RAM[0x300:], rom)
+
+	var lit [][2]int
+	for i := 0; i < 16; i++ {
+		lit = append(lit, [2]int{10 + i, 5})
+		if i > 0 {
+			lit = append(lit, [2]int{10 + i, 5 + i})
+		}
+	}
+
+	if err := cpu.draw(0x0, 0x1, 0); err != nil {
+		t.Fatalf("TestDraw16x16: failed to draw: %v", err)
+	}
+
+	if cpu.V[0xF] != 0 {
+		t.Errorf("TestDraw16x16: unexpected collision. Expected: %d Result: %d", 0, cpu.V[0xF])
+	}
+
+	assertPixels(t, "TestDraw16x16", cpu, lit)
+
+	// Drawing it again erases every pixel and collides
+	if cpu.draw(0x0, 0x1, 0); cpu.V[0xF] != 1 {
+		t.Errorf("TestDraw16x16: failed to set VF on collision. Expected: %d Result: %d", 1, cpu.V[0xF])
+	}
+
+	assertPixels(t, "TestDraw16x16: erase", cpu, nil)
+}
+
+// assertPixels checks that exactly the given (x, y) pixels are lit.
+func assertPixels(t *testing.T, name string, cpu *CPU, lit [][2]int) {
+	var expected [32][64]byte
+	for _, p := range lit {
+		expected[p[1]][p[0]] = 1
+	}
+
+	if expected != cpu.GFX {
+		t.Errorf("%s: unexpected pixels.\nExpected:\n%s\nResult:\n%s", name, gfxString(&expected), gfxString(&cpu.GFX))
+	}
 }
 
 // Instruction Ex9E: Skip next instruction if key with the value of Vx is pressed.
@@ -379,6 +1191,21 @@ func TestSkipIfKey(t *testing.T) {
 	if cpu.skipIfKey(0x0); cpu.PC != 6 {
 		t.Errorf("TestSkipIfSky: failed to properly increment PC. Expected: %d Result: %d", 6, cpu.PC)
 	}
+
+	// A held key keeps skipping every time it's checked
+	cpu.Key[0xA] = true
+	cpu.V[0x1] = 0xA
+	for expected := uint16(10); expected <= 14; expected += 4 {
+		if cpu.skipIfKey(0x1); cpu.PC != expected {
+			t.Errorf("TestSkipIfKey: failed to skip for a held key. Expected: %d Result: %d", expected, cpu.PC)
+		}
+	}
+
+	// Vx values above 0xF use the low nibble rather than indexing past the keypad
+	cpu.V[0x1] = 0xFA
+	if cpu.skipIfKey(0x1); cpu.PC != 18 {
+		t.Errorf("TestSkipIfKey: failed to mask Vx to a key. Expected: %d Result: %d", 18, cpu.PC)
+	}
 }
 
 // Instruction ExA1: Skip next instruction if key with the value of Vx is not pressed.
@@ -396,6 +1223,17 @@ func TestSkipIfKeyNot(t *testing.T) {
 	if cpu.skipIfKeyNot(0x0); cpu.PC != 6 {
 		t.Errorf("TestSkipIfKeyNot: failed to properly increment PC. Expected: %d Result: %d", 6, cpu.PC)
 	}
+
+	// Vx values above 0xF use the low nibble rather than indexing past the keypad
+	cpu.V[0x1] = 0xF0
+	if cpu.skipIfKeyNot(0x1); cpu.PC != 8 {
+		t.Errorf("TestSkipIfKeyNot: failed to mask Vx to a key. Expected: %d Result: %d", 8, cpu.PC)
+	}
+
+	cpu.Key[0x0] = false
+	if cpu.skipIfKeyNot(0x1); cpu.PC != 12 {
+		t.Errorf("TestSkipIfKeyNot: failed to mask Vx to a key. Expected: %d Result: %d", 12, cpu.PC)
+	}
 }
 
 // Instruction Fx07: Set Vx = delay timer value.
@@ -409,6 +1247,24 @@ func TestLoadXDT(t *testing.T) {
 	}
 }
 
+// Instruction Fx0A: Wait for a key press, store the value of the key in Vx.
+// All execution stops until a key is pressed, then the value of that key is stored in Vx.
+func TestLoadKey(t *testing.T) {
+	cpu := &CPU{}
+
+	// With no key pressed PC stays put and the frame ends
+	if cpu.loadKey(0x3); cpu.PC != 0 || !cpu.vblankWait {
+		t.Errorf("TestLoadKey: failed to wait for a key. Expected PC: %d Received: %d", 0, cpu.PC)
+	}
+
+	cpu.vblankWait = false
+	cpu.Key[0xB] = true
+
+	if cpu.loadKey(0x3); cpu.PC != 2 || cpu.V[0x3] != 0xB || cpu.vblankWait {
+		t.Errorf("TestLoadKey: failed to load the key. Expected V3: %d Received: %d", 0xB, cpu.V[0x3])
+	}
+}
+
 // Instruction Fx15: Set delay timer = Vx.
 // DT is set equal to the value of Vx.
 func TestLoadDTX(t *testing.T) {
@@ -442,11 +1298,99 @@ func TestAddIX(t *testing.T) {
 	}
 }
 
+func TestAddIXOverflowQuirk(t *testing.T) {
+	// Off by default: VF is left alone
+	cpu := &CPU{}
+	cpu.I = 0xFFE
+	cpu.V[0x0] = 4
+	cpu.V[0xF] = 7
+
+	if cpu.addIX(0x0); cpu.V[0xF] != 7 || cpu.I != 0x002 {
+		t.Errorf("TestAddIXOverflowQuirk: unexpected result without the quirk. VF: %d I: %d", cpu.V[0xF], cpu.I)
+	}
+
+	cpu = &CPU{}
+	cpu.Quirks.AddIOverflowSetsVF = true
+	cpu.I = 0xFFE
+	cpu.V[0x0] = 4
+
+	if cpu.addIX(0x0); cpu.V[0xF] != 1 || cpu.I != 0x002 {
+		t.Errorf("TestAddIXOverflowQuirk: failed to flag overflow. Expected VF: %d I: %d Result VF: %d I: %d", 1, 0x002, cpu.V[0xF], cpu.I)
+	}
+
+	cpu.I = 0x100
+	if cpu.addIX(0x0); cpu.V[0xF] != 0 || cpu.I != 0x104 {
+		t.Errorf("TestAddIXOverflowQuirk: failed to clear VF. Expected VF: %d I: %d Result VF: %d I: %d", 0, 0x104, cpu.V[0xF], cpu.I)
+	}
+}
+
+func TestAddIXWraps(t *testing.T) {
+	cpu := &CPU{}
+	cpu.I = 0xFFF
+	cpu.V[0x0] = 2
+
+	if cpu.addIX(0x0); cpu.I != 0x001 {
+		t.Errorf("TestAddIXWraps: failed to wrap I at the top of RAM. Expected: %d Result: %d", 0x001, cpu.I)
+	}
+
+	// Registers stored across the top of RAM wrap to the bottom instead of panicking
+	cpu.I = 0xFFE
+	cpu.V[0x1] = 7
+	cpu.V[0x2] = 9
+	cpu.saveV(0x2)
+
+	if cpu.RAM[0xFFE] != 2 || cpu.RAM[0xFFF] != 7 || cpu.RAM[0x000] != 9 {
+		t.Errorf("TestAddIXWraps: failed to wrap Fx55. Received: %d %d %d", cpu.RAM[0xFFE], cpu.RAM[0xFFF], cpu.RAM[0x000])
+	}
+
+	// XO-CHIP addresses the full 64KB
+	cpu.SetExtendedMemory(true)
+	cpu.I = 0xFFFF
+
+	if cpu.addIX(0x0); cpu.I != 0x0001 {
+		t.Errorf("TestAddIXWraps: failed to wrap I at the top of extended RAM. Expected: %d Result: %d", 0x0001, cpu.I)
+	}
+
+	if cpu.loadI(0xFFF); cpu.I != 0xFFF || cpu.MemorySize() != 0x10000 {
+		t.Errorf("TestAddIXWraps: unexpected extended address. I: %d Memory: %d", cpu.I, cpu.MemorySize())
+	}
+}
+
 // Instruction Fx29: Set I = location of sprite for digit Vx.
 // The value of I is set to the location for the hexadecimal sprite corresponding
 // to the value of Vx. See section 2.4, Display, for more information on the Chip-8 hexadecimal font.
 func TestLoadIX(t *testing.T) {
+	cpu := &CPU{}
+	cpu.Init()
+	cpu.V[0xE] = 0xA
+
+	if cpu.loadIX(0xE); cpu.I != 0xA*5 {
+		t.Errorf("TestLoadIX: failed to point I at the sprite for digit A. Expected: %d Result: %d", 0xA*5, cpu.I)
+	}
+
+	// Only the low nibble of Vx selects the digit
+	cpu.V[0xE] = 0x1A
+	if cpu.loadIX(0xE); cpu.I != 0xA*5 {
+		t.Errorf("TestLoadIX: failed to mask V%X to a digit. Expected: %d Result: %d", 0xE, 0xA*5, cpu.I)
+	}
+}
+
+// A ROM can overwrite the font, but Reset restores it.
+func TestResetRestoresFont(t *testing.T) {
+	cpu := &CPU{}
+	cpu.Init()
+	cpu.I = 0x000
+	cpu.V[0x0] = 0x42
+
+	if err := cpu.execute(0xF055); err != nil || cpu.RAM[0x000] != 0x42 {
+		t.Fatalf("TestResetRestoresFont: failed to overwrite the font. RAM[0]: %X Error: %v", cpu.RAM[0x000], err)
+	}
 
+	cpu.Reset()
+
+	if !bytes.Equal(cpu.RAM[:80], font[:]) {
+		t.Errorf("TestResetRestoresFont: failed to restore the font. Expected: %X Received: %X", font[:5], cpu.RAM[:5])
+	}
 }
 
 // Instruction Fx33: Store BCD representation of Vx in memory locations I, I+1, and I+2.
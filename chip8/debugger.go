@@ -0,0 +1,340 @@
+package CHIP8
+
+import (
+	"fmt"
+	"io"
+)
+
+// Default number of instructions StepOver runs before giving up on a subroutine returning.
+const defaultStepOverLimit = 1000000
+
+// Debugger wraps a CPU to step through a ROM and inspect it as it runs.
+type Debugger struct {
+	cpu *CPU
+
+	breakpoints         map[uint16]bool // Addresses to stop at before executing
+	watches             []*watch
+	registerBreaks      []*registerBreak
+	stepOverLimit       int
+	interpreterWritable bool // Whether PokeRAM may write below 0x200, where the fonts live
+
+	// OnWatch is called after a Step that changed a watched RAM address.
+	OnWatch func(hit WatchHit)
+}
+
+// WatchHit describes a change to a watched RAM address.
+type WatchHit struct {
+	Addr uint16 // Address that changed
+	Old  byte   // Value before the instruction
+	New  byte   // Value after the instruction
+	PC   uint16 // Address of the instruction that changed it
+}
+
+// Break describes why Continue stopped.
+type Break struct {
+	PC     uint16 // Address of the instruction that triggered the break, or of the breakpoint reached
+	Reason string
+}
+
+// Compare is the comparison a register breakpoint makes against its value.
+type Compare byte
+
+const (
+	Equal Compare = iota
+	NotEqual
+	Less
+	Greater
+)
+
+var compareSymbols = [...]string{"==", "!=", "<", ">"}
+
+func (op Compare) String() string {
+	if int(op) < len(compareSymbols) {
+		return compareSymbols[op]
+	}
+
+	return "?"
+}
+
+func (op Compare) holds(a byte, b byte) bool {
+	switch op {
+	case Equal:
+		return a == b
+	case NotEqual:
+		return a != b
+	case Less:
+		return a < b
+	case Greater:
+		return a > b
+	}
+
+	return false
+}
+
+type registerBreak struct {
+	reg   byte
+	op    Compare
+	value byte
+	held  bool // Whether the condition held after the previous instruction
+}
+
+type watch struct {
+	start  uint16
+	values []byte // Last seen values of RAM[start:start+len(values)]
+}
+
+func NewDebugger(cpu *CPU) *Debugger {
+	return &Debugger{cpu: cpu, stepOverLimit: defaultStepOverLimit}
+}
+
+// SetStepOverLimit caps how many instructions StepOver runs waiting for a subroutine to return.
+func (debugger *Debugger) SetStepOverLimit(limit int) {
+	debugger.stepOverLimit = limit
+}
+
+// Step executes a single instruction, then reports any watched address it changed.
+func (debugger *Debugger) Step() error {
+	_, err := debugger.step()
+	return err
+}
+
+// Continue steps until a breakpoint triggers, returning what triggered it.
+// If maxCycles is positive and that many instructions run without a break, it returns nil.
+func (debugger *Debugger) Continue(maxCycles int) (*Break, error) {
+	for i := 0; maxCycles <= 0 || i < maxCycles; i++ {
+		hit, err := debugger.step()
+		if err != nil {
+			return nil, err
+		}
+
+		if hit != nil {
+			return hit, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// StepOver steps a single instruction, but runs a 2nnn call through to its return, stopping at
+// the instruction after the call. It stops early if a breakpoint triggers inside the subroutine,
+// and errors if the subroutine hasn't returned within the step-over limit.
+func (debugger *Debugger) StepOver() (*Break, error) {
+	pc := debugger.cpu.PC
+	if int(pc)+1 >= len(debugger.cpu.RAM) || debugger.cpu.RAM[pc]&0xF0 != 0x20 {
+		return debugger.step()
+	}
+
+	depth := debugger.cpu.StackDepth()
+
+	for i := 0; i < debugger.stepOverLimit; i++ {
+		hit, err := debugger.step()
+		if err != nil || hit != nil {
+			return hit, err
+		}
+
+		if debugger.cpu.StackDepth() <= depth {
+			return nil, nil
+		}
+	}
+
+	return nil, fmt.Errorf("step over: subroutine called at %d didn't return within %d instructions", pc, debugger.stepOverLimit)
+}
+
+func (debugger *Debugger) step() (*Break, error) {
+	pc := debugger.cpu.PC
+
+	if err := debugger.cpu.Cycle(); err != nil {
+		return nil, err
+	}
+
+	debugger.checkWatches(pc)
+
+	if hit := debugger.checkRegisterBreaks(pc); hit != nil {
+		return hit, nil
+	}
+
+	return debugger.checkBreakpoints(), nil
+}
+
+// Snapshot returns a copy of the registers, stack and screen for display.
+func (debugger *Debugger) Snapshot() Snapshot {
+	return debugger.cpu.snapshot()
+}
+
+// CallStack returns the addresses of the active subroutine calls, outermost first.
+func (debugger *Debugger) CallStack() []uint16 {
+	return debugger.cpu.CallStack()
+}
+
+// DumpRAM saves a snapshot of RAM to the file at path.
+func (debugger *Debugger) DumpRAM(path string) error {
+	return debugger.cpu.DumpRAMToFile(path)
+}
+
+// LoadRAM restores a snapshot saved by DumpRAM. Watched addresses that differ are reported
+// on the next Step, as if it had changed them.
+func (debugger *Debugger) LoadRAM(path string) error {
+	return debugger.cpu.LoadRAMFromFile(path)
+}
+
+// PeekRAM returns the byte at addr.
+func (debugger *Debugger) PeekRAM(addr uint16) byte {
+	return debugger.cpu.PeekRAM(addr)
+}
+
+// PokeRAM changes the byte at addr, e.g. to edit sprite data and see it on the next draw.
+// The interpreter area below 0x200 is protected unless SetInterpreterWritable allows it.
+// Pokes aren't reported to OnWatch, which only reports changes made by instructions.
+func (debugger *Debugger) PokeRAM(addr uint16, value byte) error {
+	addr = debugger.cpu.addr(addr)
+
+	if addr < 0x200 && !debugger.interpreterWritable {
+		return fmt.Errorf("poke RAM: %#03x is in the interpreter area, which is protected", addr)
+	}
+
+	debugger.cpu.PokeRAM(addr, value)
+
+	for _, w := range debugger.watches {
+		if addr >= w.start && int(addr-w.start) < len(w.values) {
+			w.values[addr-w.start] = value
+		}
+	}
+
+	return nil
+}
+
+// SetInterpreterWritable sets whether PokeRAM may write the interpreter area below 0x200.
+func (debugger *Debugger) SetInterpreterWritable(writable bool) {
+	debugger.interpreterWritable = writable
+}
+
+// ViewRAM writes size bytes of RAM from addr to w as hex, 16 bytes per line.
+func (debugger *Debugger) ViewRAM(w io.Writer, addr uint16, size int) {
+	for i := 0; i < size; i += 16 {
+		fmt.Fprintf(w, "%04X:", int(addr)+i)
+
+		for j := i; j < i+16 && j < size; j++ {
+			fmt.Fprintf(w, " %02X", debugger.cpu.PeekRAM(addr+uint16(j)))
+		}
+
+		fmt.Fprintln(w)
+	}
+}
+
+// SetRegisterBreak breaks when register V[reg] becomes value.
+func (debugger *Debugger) SetRegisterBreak(reg byte, value byte) {
+	debugger.SetRegisterBreakIf(reg, Equal, value)
+}
+
+// SetRegisterBreakIf breaks when the comparison V[reg] op value becomes true.
+// It triggers on the instruction that makes the condition true, not on every
+// instruction while it stays true.
+func (debugger *Debugger) SetRegisterBreakIf(reg byte, op Compare, value byte) {
+	reg &= 0xF
+
+	debugger.registerBreaks = append(debugger.registerBreaks, &registerBreak{
+		reg:   reg,
+		op:    op,
+		value: value,
+		held:  op.holds(debugger.cpu.V[reg], value),
+	})
+}
+
+// ClearRegisterBreaks removes every register breakpoint.
+func (debugger *Debugger) ClearRegisterBreaks() {
+	debugger.registerBreaks = nil
+}
+
+// SetBreakpoint stops Continue and StepOver when PC reaches addr, before the instruction there
+// is executed.
+func (debugger *Debugger) SetBreakpoint(addr uint16) {
+	if debugger.breakpoints == nil {
+		debugger.breakpoints = map[uint16]bool{}
+	}
+
+	debugger.breakpoints[addr] = true
+}
+
+// ClearBreakpoint removes the breakpoint at addr, if there is one.
+func (debugger *Debugger) ClearBreakpoint(addr uint16) {
+	delete(debugger.breakpoints, addr)
+}
+
+func (debugger *Debugger) checkBreakpoints() *Break {
+	if pc := debugger.cpu.PC; debugger.breakpoints[pc] {
+		return &Break{PC: pc, Reason: fmt.Sprintf("PC == %#03x", pc)}
+	}
+
+	return nil
+}
+
+func (debugger *Debugger) checkRegisterBreaks(pc uint16) *Break {
+	var hit *Break
+
+	for _, b := range debugger.registerBreaks {
+		held := b.op.holds(debugger.cpu.V[b.reg], b.value)
+
+		if held && !b.held && hit == nil {
+			hit = &Break{PC: pc, Reason: fmt.Sprintf("V%X %s %#02x", b.reg, b.op, b.value)}
+		}
+
+		b.held = held
+	}
+
+	return hit
+}
+
+// SetWatch reports every change to RAM[addr] through OnWatch.
+func (debugger *Debugger) SetWatch(addr uint16) {
+	debugger.SetWatchRange(addr, 1)
+}
+
+// SetWatchRange reports every change to the size bytes of RAM starting at addr through OnWatch.
+// The range is clipped to the end of RAM.
+func (debugger *Debugger) SetWatchRange(addr uint16, size uint16) {
+	if int(addr) >= len(debugger.cpu.RAM) {
+		return
+	}
+
+	end := int(addr) + int(size)
+	if end > len(debugger.cpu.RAM) {
+		end = len(debugger.cpu.RAM)
+	}
+
+	values := make([]byte, end-int(addr))
+	copy(values, debugger.cpu.RAM[addr:end])
+
+	debugger.watches = append(debugger.watches, &watch{start: addr, values: values})
+}
+
+// ClearWatches removes every watch.
+func (debugger *Debugger) ClearWatches() {
+	debugger.watches = nil
+}
+
+func (debugger *Debugger) checkWatches(pc uint16) {
+	for _, w := range debugger.watches {
+		for i, old := range w.values {
+			addr := w.start + uint16(i)
+
+			if value := debugger.cpu.RAM[addr]; value != old {
+				w.values[i] = value
+
+				if debugger.OnWatch != nil {
+					debugger.OnWatch(WatchHit{Addr: addr, Old: old, New: value, PC: pc})
+				}
+			}
+		}
+	}
+}
+
+// Debug attaches a Debugger to the run loop and returns it. The run loop then steps through it,
+// stopping at its breakpoints until Resume is called. While stopped, the screen is still drawn
+// and input polled, but no instructions run and the timers don't count down.
+func (chip8 *Chip8) Debug() *Debugger {
+	if chip8.debugger == nil {
+		chip8.debugger = NewDebugger(chip8.cpu)
+	}
+
+	return chip8.debugger
+}
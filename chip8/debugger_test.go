@@ -0,0 +1,313 @@
+package CHIP8
+
+import (
+	"bytes"
+	"testing"
+)
+
+// newTestDebugger loads rom at 0x200 into a fresh CPU and wraps it in a Debugger.
+func newTestDebugger(rom []byte) *Debugger {
+	cpu := &CPU{}
+	cpu.Init()
+	cpu.PC = 0x200
+	copy(cpu.RAM[0x200:], rom)
+
+	return NewDebugger(cpu)
+}
+
+func TestDebuggerWatch(t *testing.T) {
+	debugger := newTestDebugger([]byte{
+		0xA3, 0x00, // 200: I = 0x300
+		0x60, 0x42, // 202: V0 = 0x42
+		0x61, 0x17, // 204: V1 = 0x17
+		0xF0, 0x55, // 206: store V0 at I
+		0xF1, 0x55, // 208: store V0 - V1 at I
+	})
+
+	var hits []WatchHit
+	debugger.OnWatch = func(hit WatchHit) {
+		hits = append(hits, hit)
+	}
+
+	debugger.SetWatch(0x300)
+
+	for i := 0; i < 4; i++ {
+		if err := debugger.Step(); err != nil {
+			t.Fatalf("TestDebuggerWatch: step %d failed: %v", i, err)
+		}
+	}
+
+	if len(hits) != 1 {
+		t.Fatalf("TestDebuggerWatch: unexpected number of watch hits. Expected: %d Received: %d", 1, len(hits))
+	}
+
+	expected := WatchHit{Addr: 0x300, Old: 0x00, New: 0x42, PC: 0x206}
+	if hits[0] != expected {
+		t.Errorf("TestDebuggerWatch: unexpected watch hit. Expected: %+v Received: %+v", expected, hits[0])
+	}
+
+	// Rewriting the same value isn't a change, but the neighbouring byte in a range is
+	hits = nil
+	debugger.ClearWatches()
+	debugger.SetWatchRange(0x300, 2)
+
+	if err := debugger.Step(); err != nil {
+		t.Fatalf("TestDebuggerWatch: step failed: %v", err)
+	}
+
+	expected = WatchHit{Addr: 0x301, Old: 0x00, New: 0x17, PC: 0x208}
+	if len(hits) != 1 || hits[0] != expected {
+		t.Errorf("TestDebuggerWatch: unexpected watch hits. Expected: [%+v] Received: %+v", expected, hits)
+	}
+}
+
+func TestDebuggerRegisterBreak(t *testing.T) {
+	debugger := newTestDebugger([]byte{
+		0x73, 0x01, // 200: V3 += 1
+		0x12, 0x00, // 202: jump 200
+	})
+	debugger.cpu.V[0x3] = 0xF0
+	debugger.SetRegisterBreak(0x3, 0xFF)
+
+	hit, err := debugger.Continue(100)
+	if err != nil {
+		t.Fatalf("TestDebuggerRegisterBreak: continue failed: %v", err)
+	}
+
+	if hit == nil {
+		t.Fatalf("TestDebuggerRegisterBreak: failed to break on V3 == 0xFF")
+	}
+
+	if hit.PC != 0x200 {
+		t.Errorf("TestDebuggerRegisterBreak: unexpected break PC. Expected: %d Received: %d", 0x200, hit.PC)
+	}
+
+	if debugger.cpu.V[0x3] != 0xFF {
+		t.Errorf("TestDebuggerRegisterBreak: broke at the wrong value. Expected: %d Received: %d", 0xFF, debugger.cpu.V[0x3])
+	}
+
+	if debugger.cpu.PC != 0x202 {
+		t.Errorf("TestDebuggerRegisterBreak: failed to stop after the triggering instruction. Expected: %d Received: %d", 0x202, debugger.cpu.PC)
+	}
+
+	// The condition has to become true again before the next break
+	debugger.ClearRegisterBreaks()
+	debugger.SetRegisterBreakIf(0x3, Greater, 0x02)
+
+	if hit, err = debugger.Continue(100); err != nil || hit == nil {
+		t.Fatalf("TestDebuggerRegisterBreak: failed to break on V3 > 2: %v", err)
+	}
+
+	if debugger.cpu.V[0x3] != 0x03 {
+		t.Errorf("TestDebuggerRegisterBreak: broke at the wrong value. Expected: %d Received: %d", 0x03, debugger.cpu.V[0x3])
+	}
+
+	if hit.Reason != "V3 > 0x02" {
+		t.Errorf("TestDebuggerRegisterBreak: unexpected reason. Expected: %q Received: %q", "V3 > 0x02", hit.Reason)
+	}
+
+	// No break within the cycle limit
+	if hit, err = debugger.Continue(10); err != nil || hit != nil {
+		t.Errorf("TestDebuggerRegisterBreak: unexpected break: %+v %v", hit, err)
+	}
+}
+
+func TestDebuggerCallStack(t *testing.T) {
+	debugger := newTestDebugger([]byte{
+		0x22, 0x04, // 200: call 204
+		0x00, 0x00, // 202:
+		0x22, 0x08, // 204: call 208
+		0x00, 0xEE, // 206: return
+		0x12, 0x08, // 208: jump 208
+	})
+
+	for i := 0; i < 3; i++ {
+		debugger.Step()
+	}
+
+	if stack := debugger.CallStack(); len(stack) != 2 || stack[0] != 0x200 || stack[1] != 0x204 {
+		t.Errorf("TestDebuggerCallStack: unexpected call stack. Expected: [%d %d] Received: %v", 0x200, 0x204, stack)
+	}
+}
+
+func TestDebuggerStepOver(t *testing.T) {
+	debugger := newTestDebugger([]byte{
+		0x22, 0x08, // 200: call 208
+		0x61, 0x01, // 202: V1 = 1
+		0x12, 0x04, // 204: jump 204
+		0x00, 0x00, // 206:
+		0x60, 0x05, // 208: V0 = 5
+		0x70, 0x01, // 20A: V0 += 1
+		0x00, 0xEE, // 20C: return
+	})
+
+	if _, err := debugger.StepOver(); err != nil {
+		t.Fatalf("TestDebuggerStepOver: step over failed: %v", err)
+	}
+
+	if debugger.cpu.PC != 0x202 {
+		t.Errorf("TestDebuggerStepOver: failed to resume after the call. Expected: %d Received: %d", 0x202, debugger.cpu.PC)
+	}
+
+	if debugger.cpu.V[0x0] != 6 || debugger.cpu.StackDepth() != 0 {
+		t.Errorf("TestDebuggerStepOver: failed to run the whole subroutine. V0: %d Depth: %d", debugger.cpu.V[0x0], debugger.cpu.StackDepth())
+	}
+
+	// Anything other than a call is a single step
+	if _, err := debugger.StepOver(); err != nil || debugger.cpu.PC != 0x204 || debugger.cpu.V[0x1] != 1 {
+		t.Errorf("TestDebuggerStepOver: failed to single step. PC: %d V1: %d Error: %v", debugger.cpu.PC, debugger.cpu.V[0x1], err)
+	}
+}
+
+func TestDebuggerStepOverLimit(t *testing.T) {
+	debugger := newTestDebugger([]byte{
+		0x22, 0x04, // 200: call 204
+		0x00, 0x00, // 202:
+		0x12, 0x04, // 204: jump 204, never returns
+	})
+	debugger.SetStepOverLimit(50)
+
+	if _, err := debugger.StepOver(); err == nil {
+		t.Errorf("TestDebuggerStepOverLimit: expected an error for a subroutine that never returns")
+	}
+}
+
+func TestDebuggerPokeRAM(t *testing.T) {
+	debugger := newTestDebugger([]byte{
+		0xA3, 0x00, // 200: I = 0x300
+		0xF1, 0x65, // 202: load V0 - V1 from I
+	})
+
+	var hits []WatchHit
+	debugger.OnWatch = func(hit WatchHit) {
+		hits = append(hits, hit)
+	}
+	debugger.SetWatch(0x301)
+
+	if err := debugger.PokeRAM(0x301, 0xAB); err != nil {
+		t.Fatalf("TestDebuggerPokeRAM: unexpected error: %v", err)
+	}
+
+	if value := debugger.PeekRAM(0x301); value != 0xAB {
+		t.Errorf("TestDebuggerPokeRAM: failed to read back the poked byte. Expected: %d Received: %d", 0xAB, value)
+	}
+
+	// The next Fx65 sees the change
+	for i := 0; i < 2; i++ {
+		if err := debugger.Step(); err != nil {
+			t.Fatalf("TestDebuggerPokeRAM: step %d failed: %v", i, err)
+		}
+	}
+
+	if debugger.cpu.V[0x1] != 0xAB {
+		t.Errorf("TestDebuggerPokeRAM: Fx65 missed the poked byte. Expected V1: %d Received: %d", 0xAB, debugger.cpu.V[0x1])
+	}
+
+	if len(hits) != 0 {
+		t.Errorf("TestDebuggerPokeRAM: a poke was reported as a watch hit: %+v", hits)
+	}
+
+	// The fonts are protected until the interpreter area is made writable
+	if err := debugger.PokeRAM(0x000, 0x00); err == nil || debugger.PeekRAM(0x000) != 0xF0 {
+		t.Errorf("TestDebuggerPokeRAM: expected the interpreter area to be protected")
+	}
+
+	debugger.SetInterpreterWritable(true)
+	if err := debugger.PokeRAM(0x000, 0x00); err != nil || debugger.PeekRAM(0x000) != 0x00 {
+		t.Errorf("TestDebuggerPokeRAM: failed to poke the writable interpreter area: %v", err)
+	}
+
+	var buf bytes.Buffer
+	debugger.ViewRAM(&buf, 0x2FE, 5)
+
+	if expected := "02FE: 00 00 00 AB 00\n"; buf.String() != expected {
+		t.Errorf("TestDebuggerPokeRAM: unexpected memory view. Expected: %q Received: %q", expected, buf.String())
+	}
+}
+
+func TestDebuggerBreakpoint(t *testing.T) {
+	debugger := newTestDebugger([]byte{
+		0x60, 0x05, // 200: V0 = 5
+		0x71, 0x01, // 202: V1 += 1
+		0x22, 0x08, // 204: call 208
+		0x12, 0x02, // 206: jump 202
+		0x72, 0x02, // 208: V2 += 2
+		0x00, 0xEE, // 20A: return
+	})
+	debugger.SetBreakpoint(0x208)
+
+	hit, err := debugger.Continue(100)
+	if err != nil {
+		t.Fatalf("TestDebuggerBreakpoint: continue failed: %v", err)
+	}
+
+	if hit == nil || hit.PC != 0x208 || hit.Reason != "PC == 0x208" {
+		t.Fatalf("TestDebuggerBreakpoint: failed to break at 0x208. Received: %+v", hit)
+	}
+
+	// Halted before executing the instruction at the breakpoint
+	state := debugger.Snapshot()
+	if state.PC != 0x208 || state.V[0] != 5 || state.V[1] != 1 || state.V[2] != 0 {
+		t.Errorf("TestDebuggerBreakpoint: unexpected state. Expected PC: %d V0: %d V1: %d V2: %d Received PC: %d V0: %d V1: %d V2: %d",
+			0x208, 5, 1, 0, state.PC, state.V[0], state.V[1], state.V[2])
+	}
+
+	if state.SP != 1 || state.Stack[0] != 0x204 {
+		t.Errorf("TestDebuggerBreakpoint: unexpected stack. Expected: [%d] Received: %v", 0x204, state.Stack[:state.SP])
+	}
+
+	// Continuing from the breakpoint runs on to the next time it's reached
+	if hit, err = debugger.Continue(100); err != nil || hit == nil || hit.PC != 0x208 {
+		t.Fatalf("TestDebuggerBreakpoint: failed to break at 0x208 again: %+v %v", hit, err)
+	}
+
+	if debugger.cpu.V[1] != 2 || debugger.cpu.V[2] != 2 {
+		t.Errorf("TestDebuggerBreakpoint: unexpected registers on the second break. Expected V1: %d V2: %d Received V1: %d V2: %d",
+			2, 2, debugger.cpu.V[1], debugger.cpu.V[2])
+	}
+
+	debugger.ClearBreakpoint(0x208)
+	if hit, err = debugger.Continue(100); err != nil || hit != nil {
+		t.Errorf("TestDebuggerBreakpoint: unexpected break after clearing the breakpoint: %+v %v", hit, err)
+	}
+}
+
+func TestChip8Debug(t *testing.T) {
+	display := &fakeDisplay{}
+	chip8 := newTestChip8(display)
+	copy(chip8.cpu.RAM[0x200:], []byte{
+		0x70, 0x01, // 200: V0 += 1
+		0x12, 0x00, // 202: jump 200
+	})
+
+	var hits []*Break
+	chip8.OnBreak = func(hit *Break) {
+		hits = append(hits, hit)
+	}
+
+	chip8.Debug().SetBreakpoint(0x202)
+	chip8.runFrame(10)
+
+	if !chip8.Paused() || len(hits) != 1 || hits[0].PC != 0x202 {
+		t.Fatalf("TestChip8Debug: failed to stop at the breakpoint. Paused: %t Hits: %v", chip8.Paused(), hits)
+	}
+
+	// Nothing runs while paused, but the display is still polled
+	polls := display.polls
+	chip8.runFrame(10)
+
+	if chip8.cpu.PC != 0x202 || chip8.cpu.V[0] != 1 {
+		t.Errorf("TestChip8Debug: ran while paused. PC: %d V0: %d", chip8.cpu.PC, chip8.cpu.V[0])
+	}
+
+	if display.polls != polls+1 {
+		t.Errorf("TestChip8Debug: failed to poll while paused. Expected: %d Received: %d", polls+1, display.polls)
+	}
+
+	chip8.Resume()
+	chip8.runFrame(10)
+
+	if len(hits) != 2 || chip8.cpu.V[0] != 2 {
+		t.Errorf("TestChip8Debug: failed to resume to the next break. Hits: %d V0: %d", len(hits), chip8.cpu.V[0])
+	}
+}
@@ -0,0 +1,66 @@
+package CHIP8
+
+import "io"
+
+// DemoConfig describes a reproducible attract mode run of a built-in ROM.
+type DemoConfig struct {
+	ROM    string    // Name of the built-in ROM to run
+	Seed   int64     // Seed for the random number generator, so Cxkk repeats too
+	Input  io.Reader // Keypad input recorded with RecordInput, or nil to press nothing
+	Frames uint64    // Number of 60Hz frames to run
+	IPF    int       // Instructions per frame, 11 if 0
+	Scale  int       // Size of a CHIP-8 pixel in the GIF, 4 if 0
+	Output io.Writer // Where the animated GIF is written
+}
+
+// RunDemo runs a demo headless and writes it to cfg.Output as an animated GIF. Everything about
+// the run is fixed by cfg, so the same config always produces the same GIF.
+func RunDemo(cfg DemoConfig) error {
+	chip8 := &Chip8{}
+	chip8.InitHeadless()
+
+	if err := chip8.LoadBuiltin(cfg.ROM); err != nil {
+		return err
+	}
+
+	chip8.Seed(cfg.Seed)
+
+	if cfg.Input != nil {
+		if err := chip8.PlayInput(cfg.Input); err != nil {
+			return err
+		}
+	}
+
+	ipf := cfg.IPF
+	if ipf == 0 {
+		ipf = 11
+	}
+
+	scale := cfg.Scale
+	if scale == 0 {
+		scale = 4
+	}
+
+	// Start from the blank screen at power on, so the GIF lasts as long as the run
+	recorder := NewGIFRecorder(scale)
+	recorder.Add(0, &chip8.cpu.GFX)
+
+	chip8.OnDraw = func(gfx *[32][64]byte) {
+		recorder.Add(chip8.frame, gfx)
+	}
+
+	// Run frames back to back rather than in real time. The timers still count 60Hz frames.
+	for chip8.frame < cfg.Frames {
+		if exit := chip8.runFrame(ipf); exit {
+			break
+		}
+	}
+
+	chip8.Shutdown()
+
+	if chip8.err != nil {
+		return chip8.err
+	}
+
+	return recorder.Encode(cfg.Output, cfg.Frames)
+}
@@ -0,0 +1,52 @@
+package CHIP8
+
+import (
+	"bytes"
+	"image/gif"
+	"strings"
+	"testing"
+)
+
+func TestRunDemo(t *testing.T) {
+	// Press 3, then A, then nothing on the keypad ROM
+	script := "0 0008\n20 0400\n40 0000\n"
+
+	run := func() []byte {
+		var buf bytes.Buffer
+		cfg := DemoConfig{ROM: "keypad", Seed: 7, Input: strings.NewReader(script), Frames: 60, Output: &buf}
+
+		if err := RunDemo(cfg); err != nil {
+			t.Fatalf("TestRunDemo: failed to run the demo: %v", err)
+		}
+
+		return buf.Bytes()
+	}
+
+	first := run()
+	if second := run(); !bytes.Equal(first, second) {
+		t.Errorf("TestRunDemo: the same config produced different GIFs. Lengths: %d %d", len(first), len(second))
+	}
+
+	anim, err := gif.DecodeAll(bytes.NewReader(first))
+	if err != nil {
+		t.Fatalf("TestRunDemo: failed to decode the GIF: %v", err)
+	}
+
+	if len(anim.Image) == 0 {
+		t.Fatalf("TestRunDemo: the GIF has no frames")
+	}
+
+	// The delays add up to the 60 frames run, one second
+	total := 0
+	for _, delay := range anim.Delay {
+		total += delay
+	}
+
+	if total != 100 {
+		t.Errorf("TestRunDemo: unexpected length. Expected: %d Received: %d", 100, total)
+	}
+
+	if err := RunDemo(DemoConfig{ROM: "missing", Frames: 1, Output: &bytes.Buffer{}}); err == nil {
+		t.Errorf("TestRunDemo: expected an error for an unknown ROM")
+	}
+}
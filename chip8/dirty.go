@@ -0,0 +1,52 @@
+package CHIP8
+
+// dirtyRect accumulates the bounding box of the pixels changed since the screen was last drawn,
+// so the display can redraw just that part. The zero value is clean.
+type dirtyRect struct {
+	x0, y0 int  // Top left corner, inclusive
+	x1, y1 int  // Bottom right corner, exclusive
+	full   bool // The whole screen needs redrawing
+}
+
+// add grows the box to include the pixel at (x, y).
+func (rect *dirtyRect) add(x int, y int) {
+	if rect.empty() {
+		rect.x0, rect.y0, rect.x1, rect.y1 = x, y, x+1, y+1
+		return
+	}
+
+	if x < rect.x0 {
+		rect.x0 = x
+	} else if x >= rect.x1 {
+		rect.x1 = x + 1
+	}
+
+	if y < rect.y0 {
+		rect.y0 = y
+	} else if y >= rect.y1 {
+		rect.y1 = y + 1
+	}
+}
+
+// markAll marks the whole screen, e.g. after it is cleared.
+func (rect *dirtyRect) markAll() {
+	rect.full = true
+}
+
+// reset marks the screen clean once it has been drawn.
+func (rect *dirtyRect) reset() {
+	*rect = dirtyRect{}
+}
+
+func (rect *dirtyRect) empty() bool {
+	return !rect.full && rect.x1 == rect.x0
+}
+
+// bounds returns the box as a position and size on a screen of the given size.
+func (rect *dirtyRect) bounds(width int, height int) (x int, y int, w int, h int) {
+	if rect.full {
+		return 0, 0, width, height
+	}
+
+	return rect.x0, rect.y0, rect.x1 - rect.x0, rect.y1 - rect.y0
+}
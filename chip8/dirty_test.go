@@ -0,0 +1,58 @@
+package CHIP8
+
+import (
+	"testing"
+)
+
+func TestDirtyRect(t *testing.T) {
+	var rect dirtyRect
+
+	if !rect.empty() {
+		t.Errorf("TestDirtyRect: expected the zero value to be clean")
+	}
+
+	rect.add(10, 5)
+	if x, y, w, h := rect.bounds(64, 32); x != 10 || y != 5 || w != 1 || h != 1 {
+		t.Errorf("TestDirtyRect: unexpected bounds for one pixel. Expected: (10, 5) 1x1 Received: (%d, %d) %dx%d", x, y, w, h)
+	}
+
+	// The box grows in every direction to cover all changes
+	rect.add(17, 9)
+	rect.add(3, 7)
+	rect.add(12, 2)
+	if x, y, w, h := rect.bounds(64, 32); x != 3 || y != 2 || w != 15 || h != 8 {
+		t.Errorf("TestDirtyRect: unexpected bounds. Expected: (3, 2) 15x8 Received: (%d, %d) %dx%d", x, y, w, h)
+	}
+
+	rect.markAll()
+	if x, y, w, h := rect.bounds(64, 32); x != 0 || y != 0 || w != 64 || h != 32 {
+		t.Errorf("TestDirtyRect: unexpected bounds after marking all. Expected: (0, 0) 64x32 Received: (%d, %d) %dx%d", x, y, w, h)
+	}
+
+	rect.reset()
+	if !rect.empty() {
+		t.Errorf("TestDirtyRect: expected a reset rect to be clean")
+	}
+}
+
+func TestDirtyRectCPU(t *testing.T) {
+	cpu := &CPU{}
+	cpu.Init()
+	cpu.V[0x0] = 20
+	cpu.V[0x1] = 10
+	cpu.I = 0x0
+
+	// The font's 0 is 4x5 pixels
+	if err := cpu.execute(0xD015); err != nil {
+		t.Fatal(err)
+	}
+
+	if x, y, w, h := cpu.dirty.bounds(64, 32); cpu.dirty.full || x != 20 || y != 10 || w != 4 || h != 5 {
+		t.Errorf("TestDirtyRectCPU: unexpected bounds after a draw. Expected: (20, 10) 4x5 Received: (%d, %d) %dx%d", x, y, w, h)
+	}
+
+	// Clearing needs a full redraw
+	if cpu.execute(0x00E0); !cpu.dirty.full {
+		t.Errorf("TestDirtyRectCPU: expected clear to mark the whole screen")
+	}
+}
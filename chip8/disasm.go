@@ -0,0 +1,131 @@
+package CHIP8
+
+import (
+	"fmt"
+)
+
+// Disassemble decodes rom, loaded at base, into one line per instruction with its address,
+// opcode and mnemonic, e.g. "0x0200  A22A  LD I, 0x22A". Words that aren't instructions, like
+// sprite data, come out as "DW 0xNNNN", and a trailing odd byte as "DB 0xNN".
+func Disassemble(rom []byte, base uint16) []string {
+	lines := make([]string, 0, (len(rom)+1)/2)
+
+	for i := 0; i < len(rom); i += 2 {
+		addr := base + uint16(i)
+
+		if i+1 == len(rom) {
+			lines = append(lines, fmt.Sprintf("0x%04X  %02X    DB 0x%02X", addr, rom[i], rom[i]))
+			break
+		}
+
+		opCode := uint16(rom[i])<<8 | uint16(rom[i+1])
+		lines = append(lines, fmt.Sprintf("0x%04X  %04X  %s", addr, opCode, mnemonic(opCode)))
+	}
+
+	return lines
+}
+
+// mnemonic decodes a single instruction the way execute does, using Cowgod's mnemonics.
+func mnemonic(opCode uint16) string {
+	vx := (opCode & 0x0F00) >> 8
+	vy := (opCode & 0x00F0) >> 4
+
+	nnn := opCode & 0x0FFF
+	kk := opCode & 0x00FF
+	n := opCode & 0x000F
+
+	switch {
+	case opCode == 0x00E0:
+		return "CLS"
+	case opCode == 0x00EE:
+		return "RET"
+	case opCode&0xFFF0 == 0x00C0:
+		return fmt.Sprintf("SCD %d", n)
+	case opCode == 0x00FB:
+		return "SCR"
+	case opCode == 0x00FC:
+		return "SCL"
+	case opCode == 0x00FE:
+		return "LOW"
+	case opCode == 0x00FF:
+		return "HIGH"
+	case opCode&0xF000 == 0x0000:
+		return fmt.Sprintf("SYS 0x%03X", nnn)
+	case opCode&0xF000 == 0x1000:
+		return fmt.Sprintf("JP 0x%03X", nnn)
+	case opCode&0xF000 == 0x2000:
+		return fmt.Sprintf("CALL 0x%03X", nnn)
+	case opCode&0xF000 == 0x3000:
+		return fmt.Sprintf("SE V%X, 0x%02X", vx, kk)
+	case opCode&0xF000 == 0x4000:
+		return fmt.Sprintf("SNE V%X, 0x%02X", vx, kk)
+	case opCode&0xF00F == 0x5000:
+		return fmt.Sprintf("SE V%X, V%X", vx, vy)
+	case opCode&0xF000 == 0x6000:
+		return fmt.Sprintf("LD V%X, 0x%02X", vx, kk)
+	case opCode&0xF000 == 0x7000:
+		return fmt.Sprintf("ADD V%X, 0x%02X", vx, kk)
+	case opCode&0xF00F == 0x8000:
+		return fmt.Sprintf("LD V%X, V%X", vx, vy)
+	case opCode&0xF00F == 0x8001:
+		return fmt.Sprintf("OR V%X, V%X", vx, vy)
+	case opCode&0xF00F == 0x8002:
+		return fmt.Sprintf("AND V%X, V%X", vx, vy)
+	case opCode&0xF00F == 0x8003:
+		return fmt.Sprintf("XOR V%X, V%X", vx, vy)
+	case opCode&0xF00F == 0x8004:
+		return fmt.Sprintf("ADD V%X, V%X", vx, vy)
+	case opCode&0xF00F == 0x8005:
+		return fmt.Sprintf("SUB V%X, V%X", vx, vy)
+	case opCode&0xF00F == 0x8006:
+		return fmt.Sprintf("SHR V%X, V%X", vx, vy)
+	case opCode&0xF00F == 0x8007:
+		return fmt.Sprintf("SUBN V%X, V%X", vx, vy)
+	case opCode&0xF00F == 0x800E:
+		return fmt.Sprintf("SHL V%X, V%X", vx, vy)
+	case opCode&0xF00F == 0x9000:
+		return fmt.Sprintf("SNE V%X, V%X", vx, vy)
+	case opCode&0xF000 == 0xA000:
+		return fmt.Sprintf("LD I, 0x%03X", nnn)
+	case opCode&0xF000 == 0xB000:
+		return fmt.Sprintf("JP V0, 0x%03X", nnn)
+	case opCode&0xF000 == 0xC000:
+		return fmt.Sprintf("RND V%X, 0x%02X", vx, kk)
+	case opCode&0xF000 == 0xD000:
+		return fmt.Sprintf("DRW V%X, V%X, %d", vx, vy, n)
+	case opCode&0xF0FF == 0xE09E:
+		return fmt.Sprintf("SKP V%X", vx)
+	case opCode&0xF0FF == 0xE0A1:
+		return fmt.Sprintf("SKNP V%X", vx)
+	case opCode&0xF0FF == 0xF007:
+		return fmt.Sprintf("LD V%X, DT", vx)
+	case opCode&0xF0FF == 0xF00A:
+		return fmt.Sprintf("LD V%X, K", vx)
+	case opCode&0xF0FF == 0xF015:
+		return fmt.Sprintf("LD DT, V%X", vx)
+	case opCode&0xF0FF == 0xF018:
+		return fmt.Sprintf("LD ST, V%X", vx)
+	case opCode&0xF0FF == 0xF01E:
+		return fmt.Sprintf("ADD I, V%X", vx)
+	case opCode&0xF0FF == 0xF029:
+		return fmt.Sprintf("LD F, V%X", vx)
+	case opCode&0xF0FF == 0xF030:
+		return fmt.Sprintf("LD HF, V%X", vx)
+	case opCode&0xF0FF == 0xF033:
+		return fmt.Sprintf("LD B, V%X", vx)
+	case opCode&0xF0FF == 0xF055:
+		return fmt.Sprintf("LD [I], V%X", vx)
+	case opCode&0xF0FF == 0xF065:
+		return fmt.Sprintf("LD V%X, [I]", vx)
+	case opCode&0xF0FF == 0xF075:
+		return fmt.Sprintf("LD R, V%X", vx)
+	case opCode&0xF0FF == 0xF085:
+		return fmt.Sprintf("LD V%X, R", vx)
+	case opCode == 0xF002:
+		return "AUDIO"
+	case opCode&0xF0FF == 0xF03A:
+		return fmt.Sprintf("PITCH V%X", vx)
+	}
+
+	return fmt.Sprintf("DW 0x%04X", opCode)
+}
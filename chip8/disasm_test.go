@@ -0,0 +1,60 @@
+package CHIP8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDisassemble(t *testing.T) {
+	rom := []byte{
+		0x00, 0xE0, // CLS
+		0xA2, 0x2A, // LD I, 0x22A
+		0x60, 0x0C, // LD V0, 0x0C
+		0x8A, 0xB4, // ADD VA, VB
+		0xD0, 0x15, // DRW V0, V1, 5
+		0xF3, 0x33, // LD B, V3
+		0xE5, 0x9E, // SKP V5
+		0x22, 0x10, // CALL 0x210
+		0x12, 0x00, // JP 0x200
+		0x00, 0xEE, // RET
+		0xFF, 0xFF, // Not an instruction
+		0x80,
+	}
+
+	expected := []string{
+		"0x0200  00E0  CLS",
+		"0x0202  A22A  LD I, 0x22A",
+		"0x0204  600C  LD V0, 0x0C",
+		"0x0206  8AB4  ADD VA, VB",
+		"0x0208  D015  DRW V0, V1, 5",
+		"0x020A  F333  LD B, V3",
+		"0x020C  E59E  SKP V5",
+		"0x020E  2210  CALL 0x210",
+		"0x0210  1200  JP 0x200",
+		"0x0212  00EE  RET",
+		"0x0214  FFFF  DW 0xFFFF",
+		"0x0216  80    DB 0x80",
+	}
+
+	lines := Disassemble(rom, 0x200)
+	if len(lines) != len(expected) {
+		t.Fatalf("TestDisassemble: unexpected number of lines. Expected: %d Received: %d", len(expected), len(lines))
+	}
+
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("TestDisassemble: unexpected line %d. Expected: %q Received: %q", i, expected[i], lines[i])
+		}
+	}
+}
+
+// The disassembler decodes exactly the instructions execute does.
+func TestDisassembleAllOpcodes(t *testing.T) {
+	for op := 0; op <= 0xFFFF; op++ {
+		unknown := strings.HasPrefix(mnemonic(uint16(op)), "DW ")
+
+		if unknown == isKnownOpcode(uint16(op)) {
+			t.Errorf("TestDisassembleAllOpcodes: %04X disassembled as %q", op, mnemonic(uint16(op)))
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package CHIP8
+
+// fadeBuffer keeps a per-pixel intensity so pixels that turn off decay over a few frames
+// instead of vanishing instantly, like the phosphor of an old CRT. This hides most of the
+// flicker caused by games erasing and redrawing sprites with XOR every frame.
+type fadeBuffer struct {
+	frames    int             // Frames a pixel takes to fade out completely
+	intensity [32][64]float64 // 1.0 is fully lit, 0.0 is the background
+}
+
+func newFadeBuffer(frames int) *fadeBuffer {
+	return &fadeBuffer{frames: frames}
+}
+
+// update advances every pixel by one frame towards its state in gfx.
+func (fade *fadeBuffer) update(gfx *[32][64]byte) {
+	for i := range gfx {
+		for j := range gfx[i] {
+			fade.intensity[i][j] = fadeStep(fade.intensity[i][j], gfx[i][j] != 0, fade.frames)
+		}
+	}
+}
+
+// fadeStep returns the intensity of a pixel one frame later. Lit pixels light up immediately,
+// and unlit ones lose 1/frames of full intensity per frame until they reach the background.
+func fadeStep(intensity float64, lit bool, frames int) float64 {
+	if lit {
+		return 1
+	}
+
+	if frames <= 0 {
+		return 0
+	}
+
+	if intensity -= 1 / float64(frames); intensity < 0 {
+		return 0
+	}
+
+	return intensity
+}
+
+// blend mixes background and foreground color channels by intensity.
+func blend(bg uint8, fg uint8, intensity float64) uint8 {
+	return uint8(float64(bg) + (float64(fg)-float64(bg))*intensity + 0.5)
+}
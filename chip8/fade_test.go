@@ -0,0 +1,55 @@
+package CHIP8
+
+import (
+	"testing"
+)
+
+func TestFadeStep(t *testing.T) {
+	intensity := fadeStep(0, true, 4)
+	if intensity != 1 {
+		t.Fatalf("TestFadeStep: lit pixel isn't fully lit. Expected: %v Received: %v", 1.0, intensity)
+	}
+
+	// Once cleared, the pixel fades monotonically and reaches the background after 4 frames
+	for frame := 1; frame <= 4; frame++ {
+		next := fadeStep(intensity, false, 4)
+
+		if next >= intensity {
+			t.Errorf("TestFadeStep: intensity didn't decrease on frame %d. Previous: %v Received: %v", frame, intensity, next)
+		}
+
+		intensity = next
+	}
+
+	if intensity != 0 {
+		t.Errorf("TestFadeStep: pixel didn't reach the background. Received: %v", intensity)
+	}
+
+	if next := fadeStep(intensity, false, 4); next != 0 {
+		t.Errorf("TestFadeStep: faded past the background. Received: %v", next)
+	}
+
+	// Without fading, pixels turn off at once
+	if next := fadeStep(1, false, 0); next != 0 {
+		t.Errorf("TestFadeStep: pixel faded with fading disabled. Received: %v", next)
+	}
+}
+
+func TestFadeBufferUpdate(t *testing.T) {
+	var gfx [32][64]byte
+	gfx[3][5] = 1
+
+	fade := newFadeBuffer(2)
+	fade.update(&gfx)
+
+	gfx[3][5] = 0
+	fade.update(&gfx)
+
+	if fade.intensity[3][5] != 0.5 {
+		t.Errorf("TestFadeBufferUpdate: unexpected intensity. Expected: %v Received: %v", 0.5, fade.intensity[3][5])
+	}
+
+	if level := blend(0, 255, fade.intensity[3][5]); level != 128 {
+		t.Errorf("TestFadeBufferUpdate: unexpected blended level. Expected: %d Received: %d", 128, level)
+	}
+}
@@ -0,0 +1,100 @@
+package CHIP8
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	fetchTimeout    = 10 * time.Second // Limit on fetching a ROM, from connecting to reading the body
+	maxDownloadSize = 1 << 20          // Largest ROM download accepted, compressed or not
+)
+
+var fetchClient = &http.Client{Timeout: fetchTimeout}
+
+// isURL reports whether a ROM filename is an http:// or https:// URL.
+func isURL(filename string) bool {
+	return strings.HasPrefix(filename, "http://") || strings.HasPrefix(filename, "https://")
+}
+
+// LoadROMURL fetches a ROM over HTTP(S) and loads it like LoadROMReader.
+func (cpu *CPU) LoadROMURL(url string) error {
+	rom, err := fetchROM(url)
+	if err != nil {
+		return err
+	}
+
+	return cpu.LoadROMReader(bytes.NewReader(rom))
+}
+
+// fetchROM downloads the bytes of a ROM over HTTP(S), as served, so possibly still gzipped.
+func fetchROM(url string) ([]byte, error) {
+	response, err := fetchClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch ROM: %s: %s", url, response.Status)
+	}
+
+	// Read one byte past the limit to tell a ROM that's exactly the limit from one that's over it
+	rom, err := ioutil.ReadAll(io.LimitReader(response.Body, maxDownloadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("fetch ROM: %s: %v", url, err)
+	}
+
+	if len(rom) > maxDownloadSize {
+		return nil, fmt.Errorf("fetch ROM: %s: larger than %d bytes", url, maxDownloadSize)
+	}
+
+	return rom, nil
+}
+
+// ReadROM returns the bytes of the ROM at filename, a path or an http:// or https:// URL, as
+// Chip8.Load would load them, decompressed if they're gzipped. It's for inspecting a ROM
+// without running it.
+func ReadROM(filename string) ([]byte, error) {
+	var r io.Reader
+
+	if isURL(filename) {
+		fetched, err := fetchROM(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		r = bytes.NewReader(fetched)
+	} else {
+		file, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		r = file
+	}
+
+	r, err := ungzip(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Limit what's decompressed like downloads, so a small file can't expand without bound
+	rom, err := ioutil.ReadAll(io.LimitReader(r, maxDownloadSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rom) > maxDownloadSize {
+		return nil, fmt.Errorf("read ROM: %s: larger than %d bytes", filename, maxDownloadSize)
+	}
+
+	return rom, nil
+}
@@ -0,0 +1,96 @@
+package CHIP8
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadROMURL(t *testing.T) {
+	rom := []byte{0x60, 0x05, 0x61, 0x06, 0x12, 0x04}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pong.ch8":
+			w.Write(rom)
+		case "/huge.ch8":
+			w.Write(make([]byte, maxDownloadSize+1))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	if !isURL(server.URL + "/pong.ch8") {
+		t.Errorf("TestLoadROMURL: failed to recognise %s as a URL", server.URL)
+	}
+
+	cpu := &CPU{}
+	if err := cpu.LoadROMURL(server.URL + "/pong.ch8"); err != nil {
+		t.Fatalf("TestLoadROMURL: failed to fetch the ROM: %v", err)
+	}
+
+	if !bytes.Equal(cpu.RAM[0x200:0x200+len(rom)], rom) || cpu.RS != len(rom) {
+		t.Errorf("TestLoadROMURL: unexpected RAM. Expected: %X Received: %X", rom, cpu.RAM[0x200:0x200+len(rom)])
+	}
+
+	if err := cpu.LoadROMURL(server.URL + "/missing.ch8"); err == nil {
+		t.Errorf("TestLoadROMURL: expected an error for a 404")
+	}
+
+	if err := cpu.LoadROMURL(server.URL + "/huge.ch8"); err == nil {
+		t.Errorf("TestLoadROMURL: expected an error for a download over the size limit")
+	}
+}
+
+func TestReadROM(t *testing.T) {
+	rom := []byte{0x60, 0x05, 0x61, 0x06, 0x12, 0x04}
+
+	var zipped bytes.Buffer
+	w := gzip.NewWriter(&zipped)
+	w.Write(rom)
+	w.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(zipped.Bytes())
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "readrom")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	plain := filepath.Join(dir, "pong.ch8")
+	gzipped := filepath.Join(dir, "pong.ch8.gz")
+
+	if err := ioutil.WriteFile(plain, rom, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(gzipped, zipped.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Files, gzipped files and gzipped downloads all read as the ROM itself
+	for _, filename := range []string{plain, gzipped, server.URL + "/pong.ch8.gz"} {
+		read, err := ReadROM(filename)
+		if err != nil {
+			t.Fatalf("TestReadROM: failed to read %s: %v", filename, err)
+		}
+
+		if !bytes.Equal(read, rom) {
+			t.Errorf("TestReadROM: unexpected bytes for %s. Expected: %X Received: %X", filename, rom, read)
+		}
+	}
+
+	if _, err := ReadROM(filepath.Join(dir, "missing.ch8")); err == nil {
+		t.Errorf("TestReadROM: expected an error for a missing file")
+	}
+}
@@ -0,0 +1,64 @@
+package CHIP8
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// Instruction Fx75: Store registers V0 through Vx in the RPL user flags, x <= 7.
+// SUPER-CHIP ran on the HP-48, which kept these flags between programs, so games use them for
+// high scores.
+func (cpu *CPU) saveFlags(vx byte) {
+	cpu.traceln("Instruction Fx75: Store registers V0 through Vx in the RPL user flags.")
+
+	// There are only 8 flags
+	if vx > 7 {
+		vx = 7
+	}
+
+	copy(cpu.Flags[:vx+1], cpu.V[:vx+1])
+	cpu.PC += 2
+}
+
+// Instruction Fx85: Read registers V0 through Vx from the RPL user flags, x <= 7.
+func (cpu *CPU) loadFlags(vx byte) {
+	cpu.traceln("Instruction Fx85: Read registers V0 through Vx from the RPL user flags.")
+
+	if vx > 7 {
+		vx = 7
+	}
+
+	copy(cpu.V[:vx+1], cpu.Flags[:vx+1])
+	cpu.PC += 2
+}
+
+// LoadFlags restores the RPL user flags saved by SaveFlags, so a game's flags outlive the
+// emulator like they did on the HP-48. A missing file leaves them cleared.
+func (chip8 *Chip8) LoadFlags(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("load flags: %v", err)
+	}
+
+	if len(data) != len(chip8.cpu.Flags) {
+		return fmt.Errorf("load flags: %s is %d bytes, expected %d", path, len(data), len(chip8.cpu.Flags))
+	}
+
+	copy(chip8.cpu.Flags[:], data)
+
+	return nil
+}
+
+// SaveFlags writes the RPL user flags to the file at path for LoadFlags.
+func (chip8 *Chip8) SaveFlags(path string) error {
+	if err := ioutil.WriteFile(path, chip8.cpu.Flags[:], 0644); err != nil {
+		return fmt.Errorf("save flags: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package CHIP8
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestFlags(t *testing.T) {
+	cpu := &CPU{}
+	cpu.Init()
+	cpu.PC = 0x200
+	copy(cpu.RAM[0x200:], []byte{
+		0x60, 0x11, // 200: V0 = 0x11
+		0x61, 0x22, // 202: V1 = 0x22
+		0x62, 0x33, // 204: V2 = 0x33
+		0xF2, 0x75, // 206: store V0-V2 in the flags
+		0x60, 0x00, // 208: V0 = 0
+		0x61, 0x00, // 20A: V1 = 0
+		0x62, 0x00, // 20C: V2 = 0
+		0xF1, 0x85, // 20E: read V0-V1 from the flags
+	})
+
+	for i := 0; i < 8; i++ {
+		if err := cpu.Cycle(); err != nil {
+			t.Fatalf("TestFlags: cycle failed: %v", err)
+		}
+	}
+
+	if cpu.Flags != [8]byte{0x11, 0x22, 0x33} {
+		t.Errorf("TestFlags: failed to store V0-V2 in the flags. Received: %X", cpu.Flags)
+	}
+
+	if cpu.V[0x0] != 0x11 || cpu.V[0x1] != 0x22 || cpu.V[0x2] != 0x00 {
+		t.Errorf("TestFlags: failed to read V0-V1 from the flags. Received: %X", cpu.V)
+	}
+
+	// Only 8 flags exist, so x is capped at 7
+	for i := range cpu.V {
+		cpu.V[i] = byte(i + 1)
+	}
+
+	if cpu.saveFlags(0xF); cpu.Flags != [8]byte{1, 2, 3, 4, 5, 6, 7, 8} {
+		t.Errorf("TestFlags: failed to store V0-V7 for x > 7. Received: %X", cpu.Flags)
+	}
+
+	cpu.Flags = [8]byte{}
+	if cpu.loadFlags(0xF); cpu.V[0x7] != 0 || cpu.V[0x8] != 9 || cpu.V[0xF] != 16 {
+		t.Errorf("TestFlags: failed to read only V0-V7 for x > 7. Received: %X", cpu.V)
+	}
+}
+
+func TestFlagsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags")
+
+	chip8 := newTestChip8(&fakeDisplay{})
+	if err := chip8.LoadFlags(path); err != nil || chip8.cpu.Flags != [8]byte{} {
+		t.Fatalf("TestFlagsFile: expected a missing file to leave the flags cleared. Error: %v Flags: %X", err, chip8.cpu.Flags)
+	}
+
+	chip8.cpu.Flags = [8]byte{0xDE, 0xAD, 0xBE, 0xEF}
+	if err := chip8.SaveFlags(path); err != nil {
+		t.Fatalf("TestFlagsFile: failed to save the flags: %v", err)
+	}
+
+	restored := newTestChip8(&fakeDisplay{})
+	if err := restored.LoadFlags(path); err != nil {
+		t.Fatalf("TestFlagsFile: failed to load the flags: %v", err)
+	}
+
+	if restored.cpu.Flags != chip8.cpu.Flags {
+		t.Errorf("TestFlagsFile: unexpected flags. Expected: %X Received: %X", chip8.cpu.Flags, restored.cpu.Flags)
+	}
+
+	if err := ioutil.WriteFile(path, []byte{1, 2, 3}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := restored.LoadFlags(path); err == nil {
+		t.Errorf("TestFlagsFile: expected an error for a truncated flags file")
+	}
+}
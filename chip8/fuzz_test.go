@@ -0,0 +1,135 @@
+package CHIP8
+
+import (
+	"errors"
+	"testing"
+)
+
+// knownOpcodes lists every instruction execute decodes as a mask and the value it must equal.
+var knownOpcodes = []struct {
+	mask  uint16
+	value uint16
+}{
+	{0xF000, 0x0000}, // 00E0, 00EE and 0nnn
+	{0xF000, 0x1000},
+	{0xF000, 0x2000},
+	{0xF000, 0x3000},
+	{0xF000, 0x4000},
+	{0xF00F, 0x5000},
+	{0xF000, 0x6000},
+	{0xF000, 0x7000},
+	{0xF00F, 0x8000},
+	{0xF00F, 0x8001},
+	{0xF00F, 0x8002},
+	{0xF00F, 0x8003},
+	{0xF00F, 0x8004},
+	{0xF00F, 0x8005},
+	{0xF00F, 0x8006},
+	{0xF00F, 0x8007},
+	{0xF00F, 0x800E},
+	{0xF00F, 0x9000},
+	{0xF000, 0xA000},
+	{0xF000, 0xB000},
+	{0xF000, 0xC000},
+	{0xF000, 0xD000},
+	{0xF0FF, 0xE09E},
+	{0xF0FF, 0xE0A1},
+	{0xF0FF, 0xF007},
+	{0xF0FF, 0xF00A},
+	{0xF0FF, 0xF015},
+	{0xF0FF, 0xF018},
+	{0xF0FF, 0xF01E},
+	{0xF0FF, 0xF029},
+	{0xF0FF, 0xF030},
+	{0xF0FF, 0xF033},
+	{0xF0FF, 0xF055},
+	{0xF0FF, 0xF065},
+	{0xF0FF, 0xF075},
+	{0xF0FF, 0xF085},
+	{0xFFFF, 0xF002},
+	{0xF0FF, 0xF03A},
+}
+
+func isKnownOpcode(opCode uint16) bool {
+	for _, known := range knownOpcodes {
+		if opCode&known.mask == known.value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkExecute runs opCode on a fresh CPU and fails if it panics or misreports an unknown opcode.
+func checkExecute(t *testing.T, opCode uint16) {
+	cpu := &CPU{}
+	cpu.Init()
+	cpu.Seed(1)
+	cpu.PC = 0x200
+	cpu.I = 0x300
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("execute panicked on %04X: %v", opCode, r)
+		}
+	}()
+
+	err := cpu.execute(opCode)
+	unknown := errors.Is(err, ErrUnknownInstruction)
+
+	if isKnownOpcode(opCode) && unknown {
+		t.Errorf("execute reported known opcode %04X as unknown", opCode)
+	}
+
+	if !isKnownOpcode(opCode) && !unknown {
+		t.Errorf("execute failed to reject unknown opcode %04X. Received: %v", opCode, err)
+	}
+}
+
+func FuzzExecute(f *testing.F) {
+	for _, known := range knownOpcodes {
+		f.Add(known.value | ^known.mask)
+	}
+	f.Add(uint16(0x5001))
+	f.Add(uint16(0x800F))
+	f.Add(uint16(0xFFFF))
+
+	f.Fuzz(func(t *testing.T, opCode uint16) {
+		checkExecute(t, opCode)
+	})
+}
+
+func TestExecuteAllOpcodes(t *testing.T) {
+	for op := 0; op <= 0xFFFF; op++ {
+		checkExecute(t, uint16(op))
+	}
+}
+
+// Every value used as an index is masked or checked, so no opcode panics even with registers,
+// I, PC and the stack at their limits.
+func TestExecuteAllOpcodesAtLimits(t *testing.T) {
+	for _, extended := range []bool{false, true} {
+		for op := 0; op <= 0xFFFF; op++ {
+			cpu := &CPU{}
+			cpu.Init()
+			cpu.SetExtendedMemory(extended)
+			cpu.PC = uint16(cpu.MemorySize() - 2)
+			cpu.I = uint16(cpu.MemorySize() - 1)
+			cpu.SP = uint16((op & 1) * len(cpu.Stack)) // Alternate between an empty and a full stack
+
+			for i := range cpu.V {
+				cpu.V[i] = 0xFF
+			}
+
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Fatalf("TestExecuteAllOpcodesAtLimits: execute panicked on %04X (extended memory: %t): %v", op, extended, r)
+					}
+				}()
+
+				cpu.execute(uint16(op))
+			}()
+		}
+	}
+}
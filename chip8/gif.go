@@ -0,0 +1,102 @@
+package CHIP8
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+)
+
+// gifPalette draws lit pixels white on black, like the PPU.
+var gifPalette = color.Palette{color.Black, color.White}
+
+// GIFRecorder collects screens into an animated GIF. Each screen is shown until the frame the
+// next one was added in, so frames that draw nothing cost nothing.
+type GIFRecorder struct {
+	Limit int // Most images to record, later ones are dropped. Unlimited if 0
+	Delay int // Time each image is shown in 1/100s, or 0 for as long as it was on screen
+
+	scale  int
+	anim   gif.GIF
+	frames []uint64 // Emulated frame each image was added in
+}
+
+// NewGIFRecorder returns a GIFRecorder drawing each CHIP-8 pixel as a scale x scale square.
+func NewGIFRecorder(scale int) *GIFRecorder {
+	if scale < 1 {
+		scale = 1
+	}
+
+	return &GIFRecorder{scale: scale}
+}
+
+// Add records the screen as drawn in the given frame, replacing any screen already added in it.
+// Frames must be added in increasing order. Screens from new frames are dropped once Full.
+func (recorder *GIFRecorder) Add(frame uint64, gfx *[32][64]byte) {
+	n := len(recorder.frames)
+	if recorder.Full() && recorder.frames[n-1] != frame {
+		return
+	}
+
+	img := image.NewPaletted(image.Rect(0, 0, 64*recorder.scale, 32*recorder.scale), gifPalette)
+
+	for i := range gfx {
+		for j := range gfx[i] {
+			if gfx[i][j] == 0 {
+				continue
+			}
+
+			for y := i * recorder.scale; y < (i+1)*recorder.scale; y++ {
+				for x := j * recorder.scale; x < (j+1)*recorder.scale; x++ {
+					img.SetColorIndex(x, y, 1)
+				}
+			}
+		}
+	}
+
+	if n > 0 && recorder.frames[n-1] == frame {
+		recorder.anim.Image[n-1] = img
+		return
+	}
+
+	recorder.anim.Image = append(recorder.anim.Image, img)
+	recorder.frames = append(recorder.frames, frame)
+}
+
+// Full reports whether Limit images have been recorded.
+func (recorder *GIFRecorder) Full() bool {
+	return recorder.Limit > 0 && len(recorder.frames) >= recorder.Limit
+}
+
+// Len returns the number of images recorded.
+func (recorder *GIFRecorder) Len() int {
+	return len(recorder.anim.Image)
+}
+
+// Encode writes the images recorded so far as a looping GIF, with the last one shown until end.
+func (recorder *GIFRecorder) Encode(w io.Writer, end uint64) error {
+	recorder.anim.Delay = make([]int, len(recorder.frames))
+
+	for i, frame := range recorder.frames {
+		if recorder.Delay > 0 {
+			recorder.anim.Delay[i] = recorder.Delay
+			continue
+		}
+
+		next := end
+		if i+1 < len(recorder.frames) {
+			next = recorder.frames[i+1]
+		}
+
+		// GIF delays are in 1/100s. Rounding each frame boundary rather than each delay keeps
+		// 60Hz frames from drifting.
+		recorder.anim.Delay[i] = centiseconds(next) - centiseconds(frame)
+	}
+
+	return gif.EncodeAll(w, &recorder.anim)
+}
+
+// centiseconds returns the time at the start of a 60Hz frame, in 1/100s.
+func centiseconds(frame uint64) int {
+	return int((frame*100 + 30) / 60)
+}
@@ -0,0 +1,91 @@
+package CHIP8
+
+import (
+	"bytes"
+	"image/gif"
+	"testing"
+)
+
+func TestGIFRecorder(t *testing.T) {
+	recorder := NewGIFRecorder(2)
+
+	var gfx [32][64]byte
+	gfx[0][0] = 1
+	recorder.Add(0, &gfx)
+
+	// A later screen in the same frame replaces the earlier one
+	gfx[0][0] = 0
+	recorder.Add(0, &gfx)
+
+	gfx[31][63] = 1
+	recorder.Add(3, &gfx)
+
+	var buf bytes.Buffer
+	if err := recorder.Encode(&buf, 60); err != nil {
+		t.Fatalf("TestGIFRecorder: failed to encode: %v", err)
+	}
+
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("TestGIFRecorder: failed to decode: %v", err)
+	}
+
+	if len(anim.Image) != 2 {
+		t.Fatalf("TestGIFRecorder: unexpected frame count. Expected: %d Received: %d", 2, len(anim.Image))
+	}
+
+	// 3 frames at 60Hz is 5/100s, and the rest of the second the remaining 95
+	if anim.Delay[0] != 5 || anim.Delay[1] != 95 {
+		t.Errorf("TestGIFRecorder: unexpected delays. Expected: [%d %d] Received: %v", 5, 95, anim.Delay)
+	}
+
+	if anim.Image[0].ColorIndexAt(0, 0) != 0 {
+		t.Errorf("TestGIFRecorder: failed to replace the first screen drawn in frame 0")
+	}
+
+	// Pixels are scaled up
+	if size := anim.Image[1].Bounds().Size(); size.X != 128 || size.Y != 64 {
+		t.Errorf("TestGIFRecorder: unexpected size. Expected: %dx%d Received: %dx%d", 128, 64, size.X, size.Y)
+	}
+
+	if anim.Image[1].ColorIndexAt(127, 63) != 1 || anim.Image[1].ColorIndexAt(125, 61) != 0 {
+		t.Errorf("TestGIFRecorder: failed to scale the lit pixel in the bottom-right corner")
+	}
+}
+
+func TestGIFRecorderLimit(t *testing.T) {
+	recorder := NewGIFRecorder(1)
+	recorder.Limit = 3
+	recorder.Delay = 10
+
+	var gfx [32][64]byte
+	for frame := uint64(0); frame < 10; frame++ {
+		gfx[0][frame] = 1
+		recorder.Add(frame, &gfx)
+	}
+
+	if !recorder.Full() || recorder.Len() != 3 {
+		t.Fatalf("TestGIFRecorderLimit: failed to stop at the limit. Expected: %d Received: %d", 3, recorder.Len())
+	}
+
+	var buf bytes.Buffer
+	if err := recorder.Encode(&buf, 10); err != nil {
+		t.Fatalf("TestGIFRecorderLimit: failed to encode: %v", err)
+	}
+
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("TestGIFRecorderLimit: failed to decode: %v", err)
+	}
+
+	// The first screens are kept, each shown for the fixed delay
+	if len(anim.Image) != 3 || anim.Image[2].ColorIndexAt(2, 0) != 1 || anim.Image[2].ColorIndexAt(3, 0) != 0 {
+		t.Errorf("TestGIFRecorderLimit: unexpected images. Expected: %d Received: %d", 3, len(anim.Image))
+	}
+
+	for i, delay := range anim.Delay {
+		if delay != 10 {
+			t.Errorf("TestGIFRecorderLimit: unexpected delay of image %d. Expected: %d Received: %d", i, 10, delay)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package CHIP8
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// Golden-frame tests load a conformance ROM from selfTests into a headless CPU, run it for a
+// fixed number of cycles, and compare the resulting GFX against a frame checked into testdata.
+// The frames are worked out from what each ROM documents it draws, never from running it, so
+// they can catch the emulator getting it wrong.
+func TestGoldenFrames(t *testing.T) {
+	for _, test := range selfTests {
+		cpu := &CPU{}
+		cpu.Init()
+
+		filename := filepath.Join("testdata", test.name+".ch8")
+		if err := cpu.LoadROM(&filename); err != nil {
+			t.Fatalf("TestGoldenFrames: failed to load %s: %v", test.name, err)
+		}
+
+		for i := uint64(0); i < test.cycles; i++ {
+			if err := cpu.Cycle(); err != nil {
+				t.Fatalf("TestGoldenFrames: %s failed on cycle %d: %v", test.name, i, err)
+			}
+		}
+
+		golden := filepath.Join("testdata", test.name+".golden")
+
+		data, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatalf("TestGoldenFrames: failed to read golden frame: %v", err)
+		}
+
+		expected, err := decodeGFX(string(data))
+		if err != nil {
+			t.Fatalf("TestGoldenFrames: malformed golden frame %s: %v", golden, err)
+		}
+
+		if expected != cpu.GFX {
+			t.Errorf("TestGoldenFrames: %s frame mismatch.\nExpected:\n%s\nReceived:\n%s",
+				test.name, gfxString(&expected), gfxString(&cpu.GFX))
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package CHIP8
+
+// NullDisplay is a Display without a window. It keeps a copy of the last frame drawn, never
+// asks to quit and leaves the keys alone, so they can be set with SetKey instead.
+type NullDisplay struct {
+	Frame  [32][64]byte // Last frame drawn
+	Frames int          // Number of frames drawn
+}
+
+func (display *NullDisplay) Draw(gfx *[32][64]byte) {
+	display.Frame = *gfx
+	display.Frames++
+}
+
+func (display *NullDisplay) Poll(key *[16]bool) bool {
+	return false
+}
+
+func (display *NullDisplay) Destroy() {}
+
+// InitHeadless initializes the CHIP-8 without SDL: there's no window, input only comes from
+// SetKey or PlayInput, and there's no sound. The run loop only ends at the cycle limit or when
+// its context is cancelled.
+func (chip8 *Chip8) InitHeadless() {
+	chip8.cpu = &CPU{}
+	chip8.cpu.Init()
+
+	chip8.ppu = &NullDisplay{}
+
+	chip8.apu = NullSound{}
+}
+
+// SetKey presses or releases one of the 16 keys. Only the low nibble of key is used. A display
+// that polls a keyboard will overwrite it on the next frame, so this is mostly for headless runs.
+func (chip8 *Chip8) SetKey(key byte, pressed bool) {
+	chip8.cpu.Key[key&0x0F] = pressed
+}
@@ -0,0 +1,82 @@
+package CHIP8
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRunHeadless(t *testing.T) {
+	chip8 := &Chip8{}
+	chip8.InitHeadless()
+
+	if err := chip8.LoadBuiltin("keypad"); err != nil {
+		t.Fatalf("TestRunHeadless: failed to load ROM: %v", err)
+	}
+
+	// The keypad ROM waits at Fx0A, so nothing is drawn until a key is pressed
+	chip8.SetCycleLimit(50)
+	if err := chip8.RunContext(context.Background(), 1000, 11); err != nil {
+		t.Fatalf("TestRunHeadless: unexpected error: %v", err)
+	}
+
+	if chip8.cpu.PC != 0x204 {
+		t.Errorf("TestRunHeadless: failed to wait for a key. Expected PC: %d Received: %d", 0x204, chip8.cpu.PC)
+	}
+
+	if gfx := gfxString(&chip8.cpu.GFX); gfx != gfxString(&[32][64]byte{}) {
+		t.Errorf("TestRunHeadless: drew before a key was pressed:\n%s", gfx)
+	}
+
+	// Holding 5 draws the digit 5 at (24, 12)
+	chip8.SetKey(0x5, true)
+	chip8.SetCycleLimit(100)
+	if err := chip8.RunContext(context.Background(), 1000, 11); err != nil {
+		t.Fatalf("TestRunHeadless: unexpected error: %v", err)
+	}
+
+	if chip8.cpu.CycleCount() != 100 {
+		t.Errorf("TestRunHeadless: failed to stop at the cycle limit. Expected: %d Received: %d", 100, chip8.cpu.CycleCount())
+	}
+
+	if chip8.cpu.V[0x0] != 0x5 {
+		t.Errorf("TestRunHeadless: failed to read the key. Expected V0: %d Received: %d", 0x5, chip8.cpu.V[0x0])
+	}
+
+	var expected [32][64]byte
+	for i := 0; i < 5; i++ {
+		for j := 0; j < 4; j++ {
+			expected[12+i][24+j] = font[5*5+i] >> uint(7-j) & 1
+		}
+	}
+
+	if gfx := gfxString(&chip8.cpu.GFX); gfx != gfxString(&expected) {
+		t.Errorf("TestRunHeadless: unexpected screen. Expected:\n%s\nReceived:\n%s", gfxString(&expected), gfx)
+	}
+}
+
+func TestNullDisplay(t *testing.T) {
+	chip8 := &Chip8{}
+	chip8.InitHeadless()
+
+	display := &NullDisplay{}
+	chip8.SetDisplay(display)
+
+	if err := chip8.LoadBuiltin("digits"); err != nil {
+		t.Fatalf("TestNullDisplay: failed to load ROM: %v", err)
+	}
+
+	for frame := 0; frame < 10; frame++ {
+		if exit := chip8.runFrame(11); exit {
+			t.Fatalf("TestNullDisplay: asked to quit on frame %d", frame)
+		}
+	}
+
+	if display.Frames == 0 {
+		t.Fatalf("TestNullDisplay: no frames drawn")
+	}
+
+	// The last frame drawn is the digit on screen
+	if display.Frame != chip8.cpu.GFX || gfxString(&display.Frame) == gfxString(&[32][64]byte{}) {
+		t.Errorf("TestNullDisplay: unexpected last frame. Expected:\n%s\nReceived:\n%s", gfxString(&chip8.cpu.GFX), gfxString(&display.Frame))
+	}
+}
@@ -0,0 +1,64 @@
+package CHIP8
+
+// hiResDisplay is implemented by displays that can show the SUPER-CHIP 128x64 screen. Others
+// are drawn a 64x32 copy of it.
+type hiResDisplay interface {
+	DrawHiRes(gfx *[64][128]byte)
+}
+
+// Instruction 00FE: Switch to the 64x32 low resolution screen.
+func (cpu *CPU) lowRes() {
+	cpu.traceln("Instruction 00FE: Switch to the 64x32 low resolution screen.")
+
+	cpu.setHiRes(false)
+	cpu.PC += 2
+}
+
+// Instruction 00FF: Switch to the 128x64 high resolution screen.
+func (cpu *CPU) highRes() {
+	cpu.traceln("Instruction 00FF: Switch to the 128x64 high resolution screen.")
+
+	cpu.setHiRes(true)
+	cpu.PC += 2
+}
+
+// setHiRes switches screens, clearing both so nothing left on the other one reappears later.
+func (cpu *CPU) setHiRes(enabled bool) {
+	cpu.HiRes = enabled
+	cpu.GFX = [32][64]byte{}
+	cpu.HiGFX = [64][128]byte{}
+	cpu.DF = true
+	cpu.dirty.markAll()
+}
+
+// screenSize returns the width and height of the screen in use.
+func (cpu *CPU) screenSize() (uint, uint) {
+	if cpu.HiRes {
+		return 128, 64
+	}
+
+	return 64, 32
+}
+
+// pixel returns the pixel at (x, y) of the screen in use.
+func (cpu *CPU) pixel(x uint, y uint) *byte {
+	if cpu.HiRes {
+		return &cpu.HiGFX[y][x]
+	}
+
+	return &cpu.GFX[y][x]
+}
+
+// downscale halves the high resolution screen for displays that can only show 64x32. A pixel
+// is lit if any of the four it replaces are.
+func downscale(hi *[64][128]byte) [32][64]byte {
+	var gfx [32][64]byte
+
+	for i := range gfx {
+		for j := range gfx[i] {
+			gfx[i][j] = hi[i*2][j*2] | hi[i*2][j*2+1] | hi[i*2+1][j*2] | hi[i*2+1][j*2+1]
+		}
+	}
+
+	return gfx
+}
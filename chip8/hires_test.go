@@ -0,0 +1,126 @@
+package CHIP8
+
+import (
+	"testing"
+)
+
+func TestHiResSwitch(t *testing.T) {
+	cpu := &CPU{}
+	cpu.Init()
+	cpu.PC = 0x200
+
+	if cpu.HiRes {
+		t.Fatalf("TestHiResSwitch: started in high resolution")
+	}
+
+	cpu.GFX[0][0] = 1
+
+	if err := cpu.execute(0x00FF); err != nil || !cpu.HiRes || cpu.PC != 0x202 {
+		t.Fatalf("TestHiResSwitch: 00FF failed to switch to high resolution. HiRes: %t PC: %d Error: %v", cpu.HiRes, cpu.PC, err)
+	}
+
+	if cpu.GFX[0][0] != 0 || !cpu.DF {
+		t.Errorf("TestHiResSwitch: failed to clear the screen when switching")
+	}
+
+	cpu.HiGFX[63][127] = 1
+
+	if err := cpu.execute(0x00FE); err != nil || cpu.HiRes || cpu.PC != 0x204 {
+		t.Fatalf("TestHiResSwitch: 00FE failed to switch to low resolution. HiRes: %t PC: %d Error: %v", cpu.HiRes, cpu.PC, err)
+	}
+
+	if cpu.HiGFX[63][127] != 0 {
+		t.Errorf("TestHiResSwitch: failed to clear the high resolution screen when switching")
+	}
+}
+
+func TestHiResDraw(t *testing.T) {
+	cpu := &CPU{}
+	cpu.Init()
+	cpu.HiRes = true
+	cpu.I = 0x300
+	cpu.RAM[0x300] = 0xC0 // Two pixels wide
+	cpu.RAM[0x301] = 0x80
+
+	// (127, 63) is off the low resolution screen, so the sprite only wraps in high resolution
+	cpu.V[0x0] = 127
+	cpu.V[0x1] = 63
+
+	if err := cpu.draw(0x0, 0x1, 2); err != nil {
+		t.Fatalf("TestHiResDraw: draw failed: %v", err)
+	}
+
+	for _, p := range [][2]int{{127, 63}, {0, 63}, {127, 0}} {
+		if cpu.HiGFX[p[1]][p[0]] != 1 {
+			t.Errorf("TestHiResDraw: pixel (%d, %d) isn't lit", p[0], p[1])
+		}
+	}
+
+	if cpu.GFX != ([32][64]byte{}) {
+		t.Errorf("TestHiResDraw: drew on the low resolution screen")
+	}
+
+	if cpu.V[0xF] != 0 {
+		t.Errorf("TestHiResDraw: unexpected collision. Expected VF: %d Received: %d", 0, cpu.V[0xF])
+	}
+
+	// Drawing again erases it
+	if cpu.draw(0x0, 0x1, 2); cpu.V[0xF] != 1 || cpu.HiGFX[63][127] != 0 {
+		t.Errorf("TestHiResDraw: failed to erase the sprite. VF: %d", cpu.V[0xF])
+	}
+}
+
+// hiResFakeDisplay is a fakeDisplay that can show the high resolution screen.
+type hiResFakeDisplay struct {
+	fakeDisplay
+	frame *[64][128]byte
+}
+
+func (display *hiResFakeDisplay) DrawHiRes(gfx *[64][128]byte) {
+	display.calls = append(display.calls, "draw hi-res")
+	display.frame = gfx
+}
+
+func TestRunFrameHiRes(t *testing.T) {
+	rom := []byte{
+		0x00, 0xFF, // 200: high resolution
+		0x60, 0x64, // 202: V0 = 100
+		0x61, 0x32, // 204: V1 = 50
+		0xA0, 0x00, // 206: I = font digit 0
+		0xD0, 0x15, // 208: draw it at (100, 50)
+		0x12, 0x0A, // 20A: jump 20A
+	}
+
+	var drawn [32][64]byte
+
+	display := &hiResFakeDisplay{}
+	chip8 := newTestChip8(&display.fakeDisplay)
+	chip8.ppu = display
+	chip8.OnDraw = func(gfx *[32][64]byte) {
+		drawn = *gfx
+	}
+	copy(chip8.cpu.RAM[0x200:], rom)
+	chip8.runFrame(6)
+
+	if display.frame == nil || display.frame[50][100] != 1 {
+		t.Fatalf("TestRunFrameHiRes: failed to draw the high resolution screen. Calls: %v", display.calls)
+	}
+
+	// OnDraw gets the screen halved, not the unused low resolution one
+	if drawn[25][50] != 1 {
+		t.Errorf("TestRunFrameHiRes: unexpected screen passed to OnDraw:\n%s", gfxString(&drawn))
+	}
+
+	// Displays without high resolution get it halved
+	drawn = [32][64]byte{}
+	chip8 = newTestChip8(&fakeDisplay{})
+	chip8.OnDraw = func(gfx *[32][64]byte) {
+		drawn = *gfx
+	}
+	copy(chip8.cpu.RAM[0x200:], rom)
+	chip8.runFrame(6)
+
+	if drawn[25][50] != 1 || drawn[50/2+5][100/2] != 0 {
+		t.Errorf("TestRunFrameHiRes: unexpected halved screen:\n%s", gfxString(&drawn))
+	}
+}
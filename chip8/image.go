@@ -0,0 +1,41 @@
+package CHIP8
+
+import "image"
+
+// ImageDisplay renders frames into an in-memory image, one image pixel per CHIP-8 pixel,
+// so frames can be hashed or diffed without SDL. It takes no input.
+type ImageDisplay struct {
+	Palette // Colours of the four pixel values, see SetColor
+
+	frame *image.RGBA
+}
+
+// ImageDisplay can stand in for the PPU in the run loop.
+var _ Display = (*ImageDisplay)(nil)
+
+// NewImageDisplay returns an ImageDisplay drawing with DefaultPalette, like the PPU.
+func NewImageDisplay() *ImageDisplay {
+	return &ImageDisplay{
+		Palette: DefaultPalette,
+		frame:   image.NewRGBA(image.Rect(0, 0, 64, 32)),
+	}
+}
+
+// Frame returns the last frame drawn. The image is reused by the next Draw.
+func (display *ImageDisplay) Frame() *image.RGBA {
+	return display.frame
+}
+
+func (display *ImageDisplay) Draw(gfx *[32][64]byte) {
+	for i := range gfx {
+		for j := range gfx[i] {
+			display.frame.SetRGBA(j, i, display.pixel(gfx[i][j]))
+		}
+	}
+}
+
+func (display *ImageDisplay) Poll(key *[16]bool) bool {
+	return false
+}
+
+func (display *ImageDisplay) Destroy() {}
@@ -0,0 +1,74 @@
+package CHIP8
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestImageDisplay(t *testing.T) {
+	cpu := &CPU{}
+	cpu.Init()
+	cpu.PC = 0x200
+	copy(cpu.RAM[0x200:], []byte{
+		0x60, 0x0A, // 200: V0 = 10
+		0x61, 0x05, // 202: V1 = 5
+		0xA0, 0x05, // 204: I = sprite for 1
+		0xD0, 0x15, // 206: draw
+	})
+
+	for i := 0; i < 4; i++ {
+		if err := cpu.Cycle(); err != nil {
+			t.Fatalf("TestImageDisplay: cycle failed: %v", err)
+		}
+	}
+
+	display := NewImageDisplay()
+	display.SetColor(1, color.RGBA{R: 0x33, G: 0xFF, B: 0x66, A: 0xFF})
+
+	display.Draw(&cpu.GFX)
+
+	frame := display.Frame()
+	if bounds := frame.Bounds(); bounds.Dx() != 64 || bounds.Dy() != 32 {
+		t.Fatalf("TestImageDisplay: unexpected frame size. Expected: 64x32 Received: %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+	// The font's 1 is 0x20 0x60 0x20 0x20 0x70
+	pixels := []struct {
+		x, y     int
+		expected color.RGBA
+	}{
+		{12, 5, display.Palette[1]},
+		{11, 6, display.Palette[1]},
+		{12, 6, display.Palette[1]},
+		{10, 5, display.Palette[0]},
+		{13, 9, display.Palette[1]},
+		{14, 9, display.Palette[0]},
+		{0, 0, display.Palette[0]},
+	}
+
+	for _, p := range pixels {
+		if received := frame.RGBAAt(p.x, p.y); received != p.expected {
+			t.Errorf("TestImageDisplay: unexpected colour at (%d, %d). Expected: %v Received: %v", p.x, p.y, p.expected, received)
+		}
+	}
+}
+
+func TestImageDisplayPlanes(t *testing.T) {
+	// One cell for each combination of the two bit planes
+	var gfx [32][64]byte
+	gfx[0][1] = 1 // Plane 0
+	gfx[0][2] = 2 // Plane 1
+	gfx[0][3] = 3 // Both
+
+	display := NewImageDisplay()
+	display.SetColor(2, color.RGBA{R: 0xFF, A: 0xFF})
+	display.SetColor(4, color.RGBA{G: 0xFF, A: 0xFF}) // Out of range, ignored
+	display.Draw(&gfx)
+
+	expected := []color.RGBA{DefaultPalette[0], DefaultPalette[1], {R: 0xFF, A: 0xFF}, DefaultPalette[3]}
+	for x, c := range expected {
+		if received := display.Frame().RGBAAt(x, 0); received != c {
+			t.Errorf("TestImageDisplayPlanes: unexpected colour for pixel value %d. Expected: %v Received: %v", x, c, received)
+		}
+	}
+}
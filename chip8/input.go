@@ -0,0 +1,110 @@
+package CHIP8
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// InputRecorder logs the keypad state of a session so it can be replayed frame for frame.
+//
+// Each line holds a frame number and the 16 keys packed into a hex mask (key 0 is bit 0).
+// A line is only written when the state differs from the previous frame, so idle frames
+// cost nothing.
+type InputRecorder struct {
+	w       io.Writer
+	last    uint16
+	started bool
+}
+
+func NewInputRecorder(w io.Writer) *InputRecorder {
+	return &InputRecorder{w: w}
+}
+
+func (recorder *InputRecorder) Record(frame uint64, key *[16]bool) error {
+	mask := packKeys(key)
+
+	if recorder.started && mask == recorder.last {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(recorder.w, "%d %04X\n", frame, mask); err != nil {
+		return fmt.Errorf("record input: %v", err)
+	}
+
+	recorder.last = mask
+	recorder.started = true
+
+	return nil
+}
+
+// InputPlayer feeds a recorded session back into the keypad, ignoring live input.
+type InputPlayer struct {
+	frames []inputFrame
+	next   int
+	state  uint16
+}
+
+type inputFrame struct {
+	frame uint64
+	mask  uint16
+}
+
+func NewInputPlayer(r io.Reader) (*InputPlayer, error) {
+	player := &InputPlayer{}
+	scanner := bufio.NewScanner(r)
+
+	for line := 1; scanner.Scan(); line++ {
+		var entry inputFrame
+
+		if _, err := fmt.Sscanf(scanner.Text(), "%d %X", &entry.frame, &entry.mask); err != nil {
+			return nil, fmt.Errorf("play input: line %d: %v", line, err)
+		}
+
+		if n := len(player.frames); n > 0 && entry.frame <= player.frames[n-1].frame {
+			return nil, fmt.Errorf("play input: line %d: frame %d is out of order", line, entry.frame)
+		}
+
+		player.frames = append(player.frames, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("play input: %v", err)
+	}
+
+	return player, nil
+}
+
+// Play overwrites key with the recorded state for the given frame.
+// Frames must be played in increasing order.
+func (player *InputPlayer) Play(frame uint64, key *[16]bool) {
+	for player.next < len(player.frames) && player.frames[player.next].frame <= frame {
+		player.state = player.frames[player.next].mask
+		player.next++
+	}
+
+	unpackKeys(player.state, key)
+}
+
+// Done reports whether every recorded frame has been played.
+func (player *InputPlayer) Done() bool {
+	return player.next >= len(player.frames)
+}
+
+func packKeys(key *[16]bool) uint16 {
+	var mask uint16
+
+	for i, pressed := range key {
+		if pressed {
+			mask |= 1 << uint(i)
+		}
+	}
+
+	return mask
+}
+
+func unpackKeys(mask uint16, key *[16]bool) {
+	for i := range key {
+		key[i] = mask&(1<<uint(i)) != 0
+	}
+}
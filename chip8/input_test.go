@@ -0,0 +1,91 @@
+package CHIP8
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Counts frames with key 0 held in V1 and folds random bytes into V3, so the final state
+// depends on both the input and the RNG.
+var inputROM = []byte{
+	0x60, 0x00, // 200: V0 = 0
+	0xE0, 0x9E, // 202: skip if key V0 is pressed
+	0x12, 0x08, // 204: jump 208
+	0x71, 0x01, // 206: V1 += 1
+	0xC2, 0xFF, // 208: V2 = random byte
+	0x83, 0x24, // 20A: V3 += V2
+	0x12, 0x02, // 20C: jump 202
+}
+
+func runInputSession(t *testing.T, frames uint64, input func(frame uint64, key *[16]bool)) *CPU {
+	cpu := &CPU{}
+	cpu.Init()
+	cpu.Seed(7)
+	cpu.PC = 0x200
+	copy(cpu.RAM[0x200:], inputROM)
+
+	for frame := uint64(0); frame < frames; frame++ {
+		if err := cpu.Cycle(); err != nil {
+			t.Fatalf("runInputSession: cycle failed on frame %d: %v", frame, err)
+		}
+
+		input(frame, &cpu.Key)
+	}
+
+	return cpu
+}
+
+func TestInputRecordAndPlay(t *testing.T) {
+	var log bytes.Buffer
+	recorder := NewInputRecorder(&log)
+
+	recorded := runInputSession(t, 200, func(frame uint64, key *[16]bool) {
+		key[0x0] = (frame >= 10 && frame < 40) || (frame >= 90 && frame < 95)
+		key[0x5] = frame >= 120 && frame < 130
+
+		if err := recorder.Record(frame, key); err != nil {
+			t.Fatalf("TestInputRecordAndPlay: failed to record frame %d: %v", frame, err)
+		}
+	})
+
+	// Only frames where the keypad changed are logged
+	if lines := strings.Count(log.String(), "\n"); lines != 7 {
+		t.Errorf("TestInputRecordAndPlay: unexpected number of recorded lines. Expected: %d Received: %d\n%s", 7, lines, log.String())
+	}
+
+	player, err := NewInputPlayer(&log)
+	if err != nil {
+		t.Fatalf("TestInputRecordAndPlay: failed to parse recording: %v", err)
+	}
+
+	replayed := runInputSession(t, 200, func(frame uint64, key *[16]bool) {
+		// Live input that must be ignored during playback
+		key[0xF] = true
+
+		player.Play(frame, key)
+	})
+
+	if !player.Done() {
+		t.Errorf("TestInputRecordAndPlay: playback finished with frames left over")
+	}
+
+	if recorded.V[0x1] == 0 {
+		t.Errorf("TestInputRecordAndPlay: the recorded session never saw key 0 pressed")
+	}
+
+	if replayed.V != recorded.V || replayed.PC != recorded.PC || replayed.I != recorded.I || replayed.Key != recorded.Key {
+		t.Errorf("TestInputRecordAndPlay: replay diverged.\nExpected: PC %d V %v Key %v\nReceived: PC %d V %v Key %v",
+			recorded.PC, recorded.V, recorded.Key, replayed.PC, replayed.V, replayed.Key)
+	}
+}
+
+func TestInputPlayerRejectsMalformed(t *testing.T) {
+	if _, err := NewInputPlayer(strings.NewReader("0 0001\nbogus\n")); err == nil {
+		t.Errorf("TestInputPlayerRejectsMalformed: expected an error for a malformed line")
+	}
+
+	if _, err := NewInputPlayer(strings.NewReader("5 0001\n3 0000\n")); err == nil {
+		t.Errorf("TestInputPlayerRejectsMalformed: expected an error for out of order frames")
+	}
+}
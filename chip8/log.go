@@ -0,0 +1,54 @@
+package CHIP8
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LogLevel is how much goes to the log set with SetLog.
+type LogLevel int
+
+const (
+	LogQuiet LogLevel = iota // Nothing, the default
+	LogInfo                  // Messages about the ROM, like the quirks detected for it
+	LogTrace                 // Also the ROM on startup, and every instruction with the registers before it
+)
+
+var logLevelNames = map[string]LogLevel{
+	"quiet": LogQuiet,
+	"info":  LogInfo,
+	"trace": LogTrace,
+}
+
+// ParseLogLevel returns the LogLevel named quiet, info or trace, ignoring case.
+func ParseLogLevel(name string) (LogLevel, error) {
+	level, ok := logLevelNames[strings.ToLower(name)]
+	if !ok {
+		return LogQuiet, fmt.Errorf("unknown log level %q, expected quiet, info or trace", name)
+	}
+
+	return level, nil
+}
+
+// OpenLog opens a log file for SetLog. mode says what happens to an existing log: truncate
+// starts it afresh, append adds to it, and rotate keeps it as path.1, replacing any older one.
+func OpenLog(path string, mode string) (*os.File, error) {
+	flags := os.O_CREATE | os.O_WRONLY
+
+	switch mode {
+	case "truncate":
+		flags |= os.O_TRUNC
+	case "append":
+		flags |= os.O_APPEND
+	case "rotate":
+		if err := os.Rename(path, path+".1"); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("open log: %v", err)
+		}
+		flags |= os.O_TRUNC
+	default:
+		return nil, fmt.Errorf("open log: unknown mode %q, expected truncate, append or rotate", mode)
+	}
+
+	return os.OpenFile(path, flags, 0644)
+}
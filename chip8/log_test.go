@@ -0,0 +1,123 @@
+package CHIP8
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chip8.log")
+
+	file, err := OpenLog(path, "truncate")
+	if err != nil {
+		t.Fatalf("TestSetLog: failed to open the log: %v", err)
+	}
+
+	chip8 := newTestChip8(&fakeDisplay{})
+	chip8.SetLog(file)
+	chip8.SetLogLevel(LogTrace)
+
+	for i := 0; i < 3; i++ {
+		if err := chip8.cpu.Cycle(); err != nil {
+			t.Fatalf("TestSetLog: cycle %d failed: %v", i, err)
+		}
+	}
+
+	file.Close()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("TestSetLog: failed to read the log: %v", err)
+	}
+
+	if n := strings.Count(string(data), "Instruction 6xkk"); n != 3 {
+		t.Errorf("TestSetLog: unexpected trace lines. Expected: %d Received: %d\n%s", 3, n, data)
+	}
+
+	if !strings.Contains(string(data), "PC: 514\tOpCode: 6000\n") {
+		t.Errorf("TestSetLog: missing the second instruction's PC in the log:\n%s", data)
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	for _, level := range []LogLevel{LogQuiet, LogInfo, LogTrace} {
+		var log bytes.Buffer
+
+		// Detecting a known ROM is logged as info
+		chip8 := loadKnownROM(t, []byte{0x60, 0x01, 0x12, 0x00}, Quirks{}, func(chip8 *Chip8) {
+			chip8.SetLog(&log)
+			chip8.SetLogLevel(level)
+		})
+		chip8.SetCycleLimit(20)
+
+		if err := chip8.RunContext(context.Background(), 1000, 10); err != nil {
+			t.Fatalf("TestLogLevel: run failed: %v", err)
+		}
+
+		detected := strings.Count(log.String(), "Detected")
+		traced := strings.Count(log.String(), "OpCode:")
+
+		switch {
+		case level == LogQuiet && log.Len() != 0:
+			t.Errorf("TestLogLevel: logged while quiet:\n%s", log.String())
+		case level == LogInfo && (detected != 1 || traced != 0):
+			t.Errorf("TestLogLevel: expected only the detected ROM at info. Received:\n%s", log.String())
+		case level == LogTrace && (detected != 1 || traced != 20):
+			t.Errorf("TestLogLevel: unexpected trace. Expected: %d instructions Received: %d", 20, traced)
+		}
+	}
+
+	if level, err := ParseLogLevel("Trace"); err != nil || level != LogTrace {
+		t.Errorf("TestLogLevel: failed to parse trace. Received: %v %v", level, err)
+	}
+
+	if _, err := ParseLogLevel("loud"); err == nil {
+		t.Errorf("TestLogLevel: expected an error for an unknown level")
+	}
+}
+
+func TestOpenLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chip8.log")
+
+	write := func(mode string, text string) {
+		file, err := OpenLog(path, mode)
+		if err != nil {
+			t.Fatalf("TestOpenLog: failed to open the log with %s: %v", mode, err)
+		}
+		defer file.Close()
+
+		file.WriteString(text)
+	}
+
+	read := func(path string) string {
+		data, _ := ioutil.ReadFile(path)
+		return string(data)
+	}
+
+	write("truncate", "one\n")
+	write("append", "two\n")
+
+	if log := read(path); log != "one\ntwo\n" {
+		t.Errorf("TestOpenLog: failed to append. Expected: %q Received: %q", "one\ntwo\n", log)
+	}
+
+	write("rotate", "three\n")
+
+	if log, old := read(path), read(path+".1"); log != "three\n" || old != "one\ntwo\n" {
+		t.Errorf("TestOpenLog: failed to rotate. Expected: %q and %q Received: %q and %q", "three\n", "one\ntwo\n", log, old)
+	}
+
+	write("truncate", "four\n")
+
+	if log := read(path); log != "four\n" {
+		t.Errorf("TestOpenLog: failed to truncate. Expected: %q Received: %q", "four\n", log)
+	}
+
+	if _, err := OpenLog(path, "shred"); err == nil {
+		t.Errorf("TestOpenLog: expected an error for an unknown mode")
+	}
+}
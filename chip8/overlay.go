@@ -0,0 +1,97 @@
+package CHIP8
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Number of recent frames the measured rates are averaged over, about a second at 60fps.
+const rateSamples = 60
+
+// rateMeter measures frames and instructions per second over a sliding window of recent frames.
+type rateMeter struct {
+	times  [rateSamples]time.Time
+	cycles [rateSamples]uint64
+	next   int // Index the next sample is written to
+	count  int // Number of samples recorded, up to rateSamples
+}
+
+// add records that a frame finished at now, with cycles instructions executed in total.
+func (meter *rateMeter) add(now time.Time, cycles uint64) {
+	meter.times[meter.next] = now
+	meter.cycles[meter.next] = cycles
+	meter.next = (meter.next + 1) % rateSamples
+
+	if meter.count < rateSamples {
+		meter.count++
+	}
+}
+
+// rates returns the average frames and instructions per second across the window.
+func (meter *rateMeter) rates() (fps float64, ips float64) {
+	if meter.count < 2 {
+		return 0, 0
+	}
+
+	newest := (meter.next - 1 + rateSamples) % rateSamples
+	oldest := (meter.next - meter.count + rateSamples) % rateSamples
+
+	elapsed := meter.times[newest].Sub(meter.times[oldest]).Seconds()
+	if elapsed <= 0 {
+		return 0, 0
+	}
+
+	fps = float64(meter.count-1) / elapsed
+	ips = float64(meter.cycles[newest]-meter.cycles[oldest]) / elapsed
+
+	return fps, ips
+}
+
+// Overlay digits are drawn with the CHIP-8 font at this many window pixels per font pixel.
+const overlayScale = 2
+
+// SetStats updates the measured rates shown by the overlay, redrawing the last frame if
+// the overlay is visible.
+func (ppu *PPU) SetStats(fps float64, ips float64) {
+	ppu.fps = fps
+	ppu.ips = ips
+
+	if ppu.overlay && ppu.last != nil {
+		ppu.Draw(ppu.last)
+	}
+}
+
+// drawOverlay draws the FPS above the IPS in the top left corner of the window.
+func (ppu *PPU) drawOverlay() {
+	lines := []string{
+		strconv.Itoa(int(ppu.fps + 0.5)),
+		strconv.Itoa(int(ppu.ips + 0.5)),
+	}
+
+	ppu.renderer.SetScale(overlayScale, overlayScale)
+
+	for row, line := range lines {
+		y := 1 + row*6
+
+		// Backdrop so the digits stay readable over lit pixels
+		ppu.renderer.SetDrawColor(0, 0, 0, 1)
+		ppu.renderer.FillRect(&sdl.Rect{X: 0, Y: int32(y - 1), W: int32(len(line)*5 + 1), H: 7})
+
+		ppu.renderer.SetDrawColor(255, 255, 0, 1)
+		for i, digit := range line {
+			glyph := font[(digit-'0')*5:]
+
+			for dy := 0; dy < 5; dy++ {
+				for dx := 0; dx < 4; dx++ {
+					if glyph[dy]&(0x80>>uint(dx)) != 0 {
+						ppu.renderer.DrawPoint(1+i*5+dx, y+dy)
+					}
+				}
+			}
+		}
+	}
+
+	ppu.renderer.SetScale(float32(ppu.pixelScale()), float32(ppu.pixelScale()))
+}
@@ -0,0 +1,40 @@
+package CHIP8
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRateMeter(t *testing.T) {
+	meter := &rateMeter{}
+
+	if fps, ips := meter.rates(); fps != 0 || ips != 0 {
+		t.Errorf("TestRateMeter: expected no rates without samples. Received: %v %v", fps, ips)
+	}
+
+	// 200 frames at 50fps with 10 instructions each
+	start := time.Unix(0, 0)
+	for frame := 0; frame < 200; frame++ {
+		meter.add(start.Add(time.Duration(frame)*20*time.Millisecond), uint64(frame*10))
+	}
+
+	fps, ips := meter.rates()
+	if math.Abs(fps-50) > 0.001 {
+		t.Errorf("TestRateMeter: unexpected FPS. Expected: %v Received: %v", 50.0, fps)
+	}
+
+	if math.Abs(ips-500) > 0.001 {
+		t.Errorf("TestRateMeter: unexpected IPS. Expected: %v Received: %v", 500.0, ips)
+	}
+
+	// Only recent frames count, so a slowdown shows up within the window
+	last := start.Add(199 * 20 * time.Millisecond)
+	for frame := 1; frame <= rateSamples; frame++ {
+		meter.add(last.Add(time.Duration(frame)*40*time.Millisecond), uint64(1990+frame*10))
+	}
+
+	if fps, _ = meter.rates(); math.Abs(fps-25) > 0.001 {
+		t.Errorf("TestRateMeter: failed to forget old frames. Expected: %v Received: %v", 25.0, fps)
+	}
+}
@@ -0,0 +1,47 @@
+package CHIP8
+
+import (
+	"encoding/hex"
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+// Palette holds the colours of the four pixel values the two XO-CHIP bit planes can make:
+// neither plane, plane 0, plane 1 and both. A pixel's low bit is plane 0 and the next plane 1.
+type Palette [4]color.RGBA
+
+// DefaultPalette draws plane 0 white and plane 1 grey on black, with light grey where they overlap.
+// Single plane ROMs only use the first two colours, so they look as they always have.
+var DefaultPalette = Palette{
+	{A: 255},
+	{R: 255, G: 255, B: 255, A: 255},
+	{R: 128, G: 128, B: 128, A: 255},
+	{R: 192, G: 192, B: 192, A: 255},
+}
+
+// SetColor sets the colour of pixel value index, from 0 to 3. Other indices are ignored.
+func (palette *Palette) SetColor(index int, c color.RGBA) {
+	if index < 0 || index >= len(palette) {
+		return
+	}
+
+	palette[index] = c
+}
+
+// pixel returns the colour of a pixel value from GFX.
+func (palette *Palette) pixel(value byte) color.RGBA {
+	return palette[value&0x3]
+}
+
+// ParseColor parses a colour written as six hex digits, RRGGBB, with or without a leading '#'.
+func ParseColor(s string) (color.RGBA, error) {
+	digits := strings.TrimPrefix(s, "#")
+
+	rgb, err := hex.DecodeString(digits)
+	if err != nil || len(rgb) != 3 {
+		return color.RGBA{}, fmt.Errorf("invalid colour %q, expected RRGGBB", s)
+	}
+
+	return color.RGBA{R: rgb[0], G: rgb[1], B: rgb[2], A: 255}, nil
+}
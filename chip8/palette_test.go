@@ -0,0 +1,72 @@
+package CHIP8
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestPaletteSetColor(t *testing.T) {
+	display := NewImageDisplay()
+	chip8 := &Chip8{cpu: &CPU{}, ppu: display, apu: &APU{}}
+
+	red := color.RGBA{R: 0xFF, A: 0xFF}
+	chip8.SetColor(3, red)
+	chip8.SetColor(-1, red)
+
+	expected := DefaultPalette
+	expected[3] = red
+
+	if display.Palette != expected {
+		t.Errorf("TestPaletteSetColor: unexpected palette. Expected: %v Received: %v", expected, display.Palette)
+	}
+
+	// Only the two plane bits pick a colour
+	if c := display.pixel(0x7); c != red {
+		t.Errorf("TestPaletteSetColor: unexpected colour for pixel value %d. Expected: %v Received: %v", 0x7, red, c)
+	}
+
+	// Displays without a palette ignore it
+	chip8.ppu = &NullDisplay{}
+	chip8.SetColor(0, red)
+}
+
+func TestSetColors(t *testing.T) {
+	display := NewImageDisplay()
+	chip8 := &Chip8{cpu: &CPU{}, ppu: display, apu: &APU{}}
+
+	amber := color.RGBA{R: 0xFF, G: 0xB0, A: 0xFF}
+	brown := color.RGBA{R: 0x20, G: 0x10, A: 0xFF}
+	chip8.SetColors(amber, brown)
+
+	var gfx [32][64]byte
+	gfx[3][4] = 1
+	display.Draw(&gfx)
+
+	if c := display.Frame().RGBAAt(4, 3); c != amber {
+		t.Errorf("TestSetColors: unexpected colour of a lit pixel. Expected: %v Received: %v", amber, c)
+	}
+
+	if c := display.Frame().RGBAAt(5, 3); c != brown {
+		t.Errorf("TestSetColors: unexpected colour of an unlit pixel. Expected: %v Received: %v", brown, c)
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	cases := map[string]color.RGBA{
+		"#FFB000": {R: 0xFF, G: 0xB0, A: 0xFF},
+		"33ff66":  {R: 0x33, G: 0xFF, B: 0x66, A: 0xFF},
+	}
+
+	for s, expected := range cases {
+		c, err := ParseColor(s)
+		if err != nil || c != expected {
+			t.Errorf("TestParseColor: failed to parse %q. Expected: %v Received: %v Error: %v", s, expected, c, err)
+		}
+	}
+
+	for _, s := range []string{"", "#FFF", "amber", "#FFB0001"} {
+		if _, err := ParseColor(s); err == nil {
+			t.Errorf("TestParseColor: expected an error for %q", s)
+		}
+	}
+}
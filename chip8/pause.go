@@ -0,0 +1,19 @@
+package CHIP8
+
+// Pause freezes emulation: no instructions run and the timers stop, silencing the beep. The run
+// loop keeps drawing the last frame and polling input, so the window stays responsive. It is
+// safe to call from any goroutine.
+func (chip8 *Chip8) Pause() {
+	chip8.paused.Store(true)
+}
+
+// Paused reports whether the run loop is paused, by Pause or at a breakpoint. It is safe to call
+// from any goroutine.
+func (chip8 *Chip8) Paused() bool {
+	return chip8.paused.Load()
+}
+
+// Resume carries on running after Pause or a breakpoint. It is safe to call from any goroutine.
+func (chip8 *Chip8) Resume() {
+	chip8.paused.Store(false)
+}
@@ -0,0 +1,62 @@
+package CHIP8
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPause(t *testing.T) {
+	display := &fakeDisplay{}
+	sound := &fakeSound{}
+	chip8 := newTestChip8(display)
+	chip8.SetSound(sound)
+	chip8.cpu.ST = 30
+
+	chip8.runFrame(5)
+	if !sound.playing {
+		t.Fatalf("TestPause: failed to start the beep")
+	}
+
+	// Pausing from another goroutine is picked up by the next frame
+	done := make(chan struct{})
+	go func() {
+		chip8.Pause()
+		close(done)
+	}()
+	<-done
+
+	if !chip8.Paused() {
+		t.Fatalf("TestPause: failed to pause")
+	}
+
+	pc, st := chip8.cpu.PC, chip8.cpu.ST
+	display.calls = nil
+
+	for frame := 0; frame < 5; frame++ {
+		chip8.runFrame(5)
+	}
+
+	if chip8.cpu.PC != pc || chip8.cpu.ST != st {
+		t.Errorf("TestPause: ran while paused. Expected PC: %X ST: %d Received PC: %X ST: %d", pc, st, chip8.cpu.PC, chip8.cpu.ST)
+	}
+
+	// The window is kept up: redrawn and polled every frame
+	if expected := []string{"draw", "poll", "draw", "poll", "draw", "poll", "draw", "poll", "draw", "poll"}; fmt.Sprint(display.calls) != fmt.Sprint(expected) {
+		t.Errorf("TestPause: unexpected display calls. Expected: %v Received: %v", expected, display.calls)
+	}
+
+	if sound.playing {
+		t.Errorf("TestPause: kept beeping while paused")
+	}
+
+	chip8.Resume()
+	chip8.runFrame(5)
+
+	if chip8.Paused() || chip8.cpu.PC != pc+10 {
+		t.Errorf("TestPause: failed to resume. Expected PC: %X Received: %X", pc+10, chip8.cpu.PC)
+	}
+
+	if !sound.playing {
+		t.Errorf("TestPause: failed to beep again after resuming")
+	}
+}
@@ -0,0 +1,66 @@
+package CHIP8
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrMisalignedPC is returned by Cycle with PCStrict when PC is odd outside the font area.
+var ErrMisalignedPC = errors.New("misaligned program counter")
+
+// PCCheck is what the CPU does when PC lands on an odd address above the fonts. Instructions
+// are 2 bytes and ROMs start at 0x200, so that's almost always a bad jump into the middle of one.
+type PCCheck int
+
+const (
+	PCLenient PCCheck = iota // Run whatever opcode the two bytes make, like the original interpreter
+	PCWarn                   // Log a warning the first time each odd address runs, then carry on
+	PCStrict                 // Stop with ErrMisalignedPC
+)
+
+var pcCheckNames = map[string]PCCheck{
+	"lenient": PCLenient,
+	"warn":    PCWarn,
+	"strict":  PCStrict,
+}
+
+// ParsePCCheck returns the PCCheck named lenient, warn or strict, ignoring case.
+func ParsePCCheck(name string) (PCCheck, error) {
+	check, ok := pcCheckNames[strings.ToLower(name)]
+	if !ok {
+		return PCLenient, fmt.Errorf("unknown PC check %q, expected lenient, warn or strict", name)
+	}
+
+	return check, nil
+}
+
+// checkPC applies the PC check before an instruction is fetched.
+func (cpu *CPU) checkPC() error {
+	if cpu.PCCheck == PCLenient || cpu.PC&1 == 0 || cpu.PC < 0x200 {
+		return nil
+	}
+
+	if cpu.PCCheck == PCStrict {
+		return fmt.Errorf("%w: PC %04X is odd", ErrMisalignedPC, cpu.PC)
+	}
+
+	if cpu.warned[cpu.PC] {
+		return nil
+	}
+
+	if cpu.warned == nil {
+		cpu.warned = make(map[uint16]bool)
+	}
+	cpu.warned[cpu.PC] = true
+
+	w := cpu.warnLog
+	if w == nil {
+		w = os.Stderr
+	}
+
+	fmt.Fprintf(w, "warning: %v: PC %04X is odd, called from %v\n", ErrMisalignedPC, cpu.PC, cpu.CallStack())
+
+	return nil
+}
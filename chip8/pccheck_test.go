@@ -0,0 +1,87 @@
+package CHIP8
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParsePCCheck(t *testing.T) {
+	for name, expected := range map[string]PCCheck{"lenient": PCLenient, "Warn": PCWarn, "STRICT": PCStrict} {
+		if check, err := ParsePCCheck(name); err != nil || check != expected {
+			t.Errorf("TestParsePCCheck: unexpected check for %q. Expected: %d Received: %d (%v)", name, expected, check, err)
+		}
+	}
+
+	if _, err := ParsePCCheck("pedantic"); err == nil {
+		t.Errorf("TestParsePCCheck: expected an error for an unknown check")
+	}
+}
+
+func TestCheckPC(t *testing.T) {
+	// Jump from 0x200 to the odd address 0x205, where 6A12 straddles two instructions
+	rom := []byte{0x12, 0x05, 0x00, 0x00, 0x00, 0x6A, 0x12, 0x00}
+
+	newCPU := func(check PCCheck) *CPU {
+		cpu := &CPU{}
+		cpu.Init()
+		cpu.PC = 0x200
+		cpu.PCCheck = check
+		copy(cpu.RAM[0x200:], rom)
+
+		return cpu
+	}
+
+	// Lenient runs the straddling instruction
+	cpu := newCPU(PCLenient)
+	for i := 0; i < 2; i++ {
+		if err := cpu.Cycle(); err != nil {
+			t.Fatalf("TestCheckPC: unexpected error when lenient: %v", err)
+		}
+	}
+
+	if cpu.V[0xA] != 0x12 {
+		t.Errorf("TestCheckPC: failed to run from an odd PC when lenient. Expected VA: %d Received: %d", 0x12, cpu.V[0xA])
+	}
+
+	// Strict stops before fetching
+	cpu = newCPU(PCStrict)
+	cpu.Cycle()
+
+	if err := cpu.Cycle(); !errors.Is(err, ErrMisalignedPC) {
+		t.Errorf("TestCheckPC: unexpected error when strict. Expected: %v Received: %v", ErrMisalignedPC, err)
+	}
+
+	if cpu.PC != 0x205 || cpu.V[0xA] != 0 {
+		t.Errorf("TestCheckPC: ran the instruction when strict. Expected PC: %d Received: %d", 0x205, cpu.PC)
+	}
+
+	// Warn logs the address once and carries on
+	var log bytes.Buffer
+	cpu = newCPU(PCWarn)
+	cpu.warnLog = &log
+	cpu.Cycle()
+
+	for i := 0; i < 2; i++ {
+		cpu.PC = 0x205
+		if err := cpu.Cycle(); err != nil {
+			t.Fatalf("TestCheckPC: unexpected error when warning: %v", err)
+		}
+	}
+
+	if strings.Count(log.String(), "PC 0205 is odd") != 1 {
+		t.Errorf("TestCheckPC: expected one warning for PC %04X. Received: %q", 0x205, log.String())
+	}
+
+	if cpu.V[0xA] != 0x12 {
+		t.Errorf("TestCheckPC: failed to carry on after warning. Expected VA: %d Received: %d", 0x12, cpu.V[0xA])
+	}
+
+	// The fonts live below 0x200 and aren't checked
+	cpu = newCPU(PCStrict)
+	cpu.PC = 0x001
+	if err := cpu.checkPC(); err != nil {
+		t.Errorf("TestCheckPC: unexpected error below 0x200: %v", err)
+	}
+}
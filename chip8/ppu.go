@@ -1,44 +1,74 @@
 package CHIP8
 
 import (
+	"image/color"
+	"path/filepath"
+	"strings"
+
 	"github.com/veandco/go-sdl2/sdl"
 )
 
 type PPU struct {
-	window   *sdl.Window
-	renderer *sdl.Renderer
-	keypad map[sdl.Scancode]byte
+	window    *sdl.Window
+	renderer  *sdl.Renderer
+	texture   *sdl.Texture // Streaming texture holding the screen, one texel per CHIP-8 pixel
+	pixels    []byte       // ARGB8888 copy of the texture, updated a region at a time
+	hiTexture *sdl.Texture // Streaming texture holding the SUPER-CHIP 128x64 screen
+	hiPixels  []byte       // ARGB8888 copy of hiTexture
+	keypad    map[sdl.Scancode]byte
+
+	scale   int         // Window pixels per CHIP-8 pixel, defaultScale if 0
+	fade    *fadeBuffer // Phosphor fade, or nil to draw pixels crisply
+	gap     int         // Window pixels left unlit between neighbouring CHIP-8 pixels
+	palette Palette     // Colours of the four pixel values
+
+	overlay bool          // Whether the FPS/IPS overlay is visible, toggled with F3
+	fps     float64       // Measured frames per second
+	ips     float64       // Measured instructions per second
+	last    *[32][64]byte // Last frame drawn, for redrawing the overlay
+
+	rewinding bool // Whether Backspace is held to step back through recent frames
 }
 
+// DefaultKeymap maps the left of a QWERTY keyboard to the keypad, keeping its 4x4 layout:
+//
+//	1 2 3 4        1 2 3 C
+//	Q W E R        4 5 6 D
+//	A S D F   ->   7 8 9 E
+//	Z X C V        A 0 B F
+var DefaultKeymap = map[sdl.Scancode]byte{
+	sdl.SCANCODE_1: 0x1,
+	sdl.SCANCODE_2: 0x2,
+	sdl.SCANCODE_3: 0x3,
+	sdl.SCANCODE_Q: 0x4,
+	sdl.SCANCODE_W: 0x5,
+	sdl.SCANCODE_E: 0x6,
+	sdl.SCANCODE_A: 0x7,
+	sdl.SCANCODE_S: 0x8,
+	sdl.SCANCODE_D: 0x9,
+	sdl.SCANCODE_X: 0x0,
+	sdl.SCANCODE_Z: 0xA,
+	sdl.SCANCODE_C: 0xB,
+	sdl.SCANCODE_4: 0xC,
+	sdl.SCANCODE_R: 0xD,
+	sdl.SCANCODE_F: 0xE,
+	sdl.SCANCODE_V: 0xF,
+}
 
 const (
-	title  = "CHIP-8"
-	height = 320
-	width  = 640
+	title        = "CHIP-8"
+	defaultScale = 10 // Window pixels per CHIP-8 pixel, see SetScale
 )
 
 func (ppu *PPU) Init() error {
-	ppu.keypad = map[sdl.Scancode]byte {
-		sdl.SCANCODE_1: 0x1,
-		sdl.SCANCODE_2: 0x2,
-		sdl.SCANCODE_3: 0x3,
-		sdl.SCANCODE_Q: 0x4,
-		sdl.SCANCODE_W: 0x5,
-		sdl.SCANCODE_E: 0x6,
-		sdl.SCANCODE_A: 0x7,
-		sdl.SCANCODE_S: 0x8,
-		sdl.SCANCODE_D: 0x9,
-		sdl.SCANCODE_X: 0x0,
-		sdl.SCANCODE_Z: 0xA,
-		sdl.SCANCODE_C: 0xB,
-		sdl.SCANCODE_4: 0xC,
-		sdl.SCANCODE_R: 0xD,
-		sdl.SCANCODE_F: 0xE,
-		sdl.SCANCODE_V: 0xF}
+	ppu.palette = DefaultPalette
+
+	ppu.SetKeymap(DefaultKeymap)
 
 	var err error
 	err = sdl.Init(sdl.INIT_VIDEO | sdl.INIT_AUDIO)
 
+	width, height := windowSize(ppu.pixelScale())
 	if ppu.window, err = sdl.CreateWindow(title, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED, width, height, sdl.WINDOW_SHOWN); err != nil {
 		return err
 	}
@@ -47,9 +77,13 @@ func (ppu *PPU) Init() error {
 		return err
 	}
 
-	ppu.renderer.SetScale(10, 10)
+	ppu.renderer.SetScale(float32(ppu.pixelScale()), float32(ppu.pixelScale()))
+
+	if err = ppu.createTexture(); err != nil {
+		return err
+	}
 
-	rect := sdl.Rect{0, 0, width, height}
+	rect := sdl.Rect{X: 0, Y: 0, W: int32(width), H: int32(height)}
 	ppu.renderer.SetDrawColor(0, 0, 0, 1)
 	ppu.renderer.FillRect(&rect)
 	ppu.renderer.Present()
@@ -57,48 +91,342 @@ func (ppu *PPU) Init() error {
 	return nil
 }
 
-func (ppu *PPU) destroy() {
+// createTexture creates the streaming texture the screen is drawn through. Textures belong to
+// a renderer, so this is needed again whenever the renderer is recreated.
+func (ppu *PPU) createTexture() error {
+	texture, err := ppu.renderer.CreateTexture(sdl.PIXELFORMAT_ARGB8888, sdl.TEXTUREACCESS_STREAMING, 64, 32)
+	if err != nil {
+		return err
+	}
+
+	ppu.texture = texture
+	ppu.pixels = make([]byte, 64*32*4)
+
+	hiTexture, err := ppu.renderer.CreateTexture(sdl.PIXELFORMAT_ARGB8888, sdl.TEXTUREACCESS_STREAMING, 128, 64)
+	if err != nil {
+		return err
+	}
+
+	ppu.hiTexture = hiTexture
+	ppu.hiPixels = make([]byte, 128*64*4)
+
+	return nil
+}
+
+// Tear down in the reverse order of Init: textures, renderer, then window, then SDL itself.
+func (ppu *PPU) Destroy() {
+	ppu.hiTexture.Destroy()
+	ppu.texture.Destroy()
 	ppu.renderer.Destroy()
 	ppu.window.Destroy()
 	sdl.Quit()
 }
 
+// SetVSync recreates the renderer so presenting waits for the display's vertical refresh, or not.
+func (ppu *PPU) SetVSync(enabled bool) error {
+	var flags uint32
+	if enabled {
+		flags = sdl.RENDERER_PRESENTVSYNC
+	}
+
+	renderer, err := sdl.CreateRenderer(ppu.window, -1, flags)
+	if err != nil {
+		return err
+	}
+
+	ppu.hiTexture.Destroy()
+	ppu.texture.Destroy()
+	ppu.renderer.Destroy()
+	ppu.renderer = renderer
+	ppu.renderer.SetScale(float32(ppu.pixelScale()), float32(ppu.pixelScale()))
+
+	return ppu.createTexture()
+}
+
+// SetTitle names the window after the running ROM, e.g. "CHIP-8 — PONG".
+// An empty name resets it to plain "CHIP-8".
+func (ppu *PPU) SetTitle(name string) {
+	ppu.window.SetTitle(windowTitle(name))
+}
+
+func windowTitle(name string) string {
+	if name == "" {
+		return title
+	}
+
+	return title + " — " + name
+}
+
+// romName returns the base name of a ROM file without its extension.
+func romName(filename string) string {
+	base := filepath.Base(filename)
+	if base == "." || base == string(filepath.Separator) {
+		return ""
+	}
+
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// SetColor sets the colour of pixel value index, from 0 to 3: the background, XO-CHIP plane 0,
+// plane 1 and both. It shows from the next frame drawn.
+func (ppu *PPU) SetColor(index int, c color.RGBA) {
+	ppu.palette.SetColor(index, c)
+}
+
+// SetFade makes pixels that turn off fade out over the given number of frames instead of
+// disappearing at once. 0 turns fading off. Fading needs Draw to be called every frame.
+func (ppu *PPU) SetFade(frames int) {
+	if frames > 0 {
+		ppu.fade = newFadeBuffer(frames)
+	} else {
+		ppu.fade = nil
+	}
+}
+
+// SetKeymap replaces the keys mapped to the keypad with a copy of keymap. Keys held when it's
+// changed may stay pressed until pressed again.
+func (ppu *PPU) SetKeymap(keymap map[sdl.Scancode]byte) {
+	ppu.keypad = make(map[sdl.Scancode]byte, len(keymap))
+
+	for scancode, key := range keymap {
+		ppu.keypad[scancode] = key & 0x0F
+	}
+}
+
+// SetScale sizes the window to show each CHIP-8 pixel as an n x n square, 64n by 32n window
+// pixels. Any whole n from 1 up works, odd or even; smaller values are treated as 1. A pixel gap
+// too wide for the new scale is narrowed.
+func (ppu *PPU) SetScale(n int) {
+	if n < 1 {
+		n = 1
+	}
+
+	ppu.scale = n
+	ppu.SetPixelGap(ppu.gap)
+
+	if ppu.window != nil {
+		ppu.window.SetSize(windowSize(n))
+		ppu.renderer.SetScale(float32(n), float32(n))
+	}
+}
+
+// pixelScale returns the window pixels per CHIP-8 pixel.
+func (ppu *PPU) pixelScale() int {
+	if ppu.scale == 0 {
+		return defaultScale
+	}
+
+	return ppu.scale
+}
+
+// windowSize returns the size of a window showing the 64x32 screen at the given scale.
+func windowSize(scale int) (int, int) {
+	return 64 * scale, 32 * scale
+}
+
+// SetPixelGap leaves a gap of the given number of window pixels between neighbouring pixels,
+// for a grid or LCD look. 0 draws pixels solid. The gap is limited so pixels stay visible.
+func (ppu *PPU) SetPixelGap(gap int) {
+	if gap < 0 {
+		gap = 0
+	} else if gap > ppu.pixelScale()-1 {
+		gap = ppu.pixelScale() - 1
+	}
+
+	ppu.gap = gap
+}
+
+// pixelRect returns the window area lit for the pixel at (row, col), with the gap split
+// evenly around it.
+func pixelRect(row int, col int, scale int, gap int) sdl.Rect {
+	return sdl.Rect{
+		X: int32(col*scale + gap/2),
+		Y: int32(row*scale + gap/2),
+		W: int32(scale - gap),
+		H: int32(scale - gap),
+	}
+}
+
+// beginFrame prepares the renderer for a frame of drawPixel calls.
+func (ppu *PPU) beginFrame() {
+	if ppu.gap == 0 {
+		return
+	}
+
+	// Gapped pixels are drawn in window pixels over a background that shows through the gaps
+	bg := ppu.palette[0]
+	ppu.renderer.SetScale(1, 1)
+	ppu.renderer.SetDrawColor(bg.R, bg.G, bg.B, bg.A)
+	width, height := windowSize(ppu.pixelScale())
+	ppu.renderer.FillRect(&sdl.Rect{X: 0, Y: 0, W: int32(width), H: int32(height)})
+}
+
+// drawPixel fills the pixel at (row, col) in the current draw colour.
+func (ppu *PPU) drawPixel(row int, col int) {
+	if ppu.gap == 0 {
+		ppu.renderer.DrawPoint(col, row)
+		return
+	}
+
+	rect := pixelRect(row, col, ppu.pixelScale(), ppu.gap)
+	ppu.renderer.FillRect(&rect)
+}
+
 func (ppu *PPU) Draw(gfx *[32][64]byte) {
+	if ppu.fade == nil && ppu.gap == 0 {
+		ppu.DrawRegion(gfx, 0, 0, 64, 32)
+		return
+	}
+
+	ppu.last = gfx
+	ppu.beginFrame()
+
+	if ppu.fade != nil {
+		ppu.drawFaded(gfx)
+		return
+	}
+
 	for i := 0; i < 32; i++ {
 		for j := 0; j < 64; j++ {
-			pixel := gfx[i][j]
+			c := ppu.palette.pixel(gfx[i][j])
+
+			ppu.renderer.SetDrawColor(c.R, c.G, c.B, c.A)
+			ppu.drawPixel(i, j)
+		}
+	}
+
+	ppu.present()
+}
+
+// DrawRegion updates only the w x h pixels at (x, y) of the screen texture, then presents the
+// whole screen. Fading and pixel gaps aren't drawn through the texture, so they redraw everything.
+func (ppu *PPU) DrawRegion(gfx *[32][64]byte, x int, y int, w int, h int) {
+	if ppu.fade != nil || ppu.gap > 0 {
+		ppu.Draw(gfx)
+		return
+	}
+
+	ppu.last = gfx
+
+	for i := y; i < y+h; i++ {
+		for j := x; j < x+w; j++ {
+			// ARGB8888 is stored blue first on little endian machines
+			c := ppu.palette.pixel(gfx[i][j])
+
+			offset := (i*64 + j) * 4
+			ppu.pixels[offset] = c.B
+			ppu.pixels[offset+1] = c.G
+			ppu.pixels[offset+2] = c.R
+			ppu.pixels[offset+3] = c.A
+		}
+	}
+
+	if w > 0 && h > 0 {
+		rect := sdl.Rect{X: int32(x), Y: int32(y), W: int32(w), H: int32(h)}
+		ppu.texture.Update(&rect, ppu.pixels[(y*64+x)*4:], 64*4)
+	}
+
+	ppu.renderer.Copy(ppu.texture, nil, nil)
+	ppu.present()
+}
 
-			if pixel == 0 {
-				ppu.renderer.SetDrawColor(0, 0, 0, 1)
-			} else {
-				ppu.renderer.SetDrawColor(255, 255, 255, 1)
+// DrawHiRes presents the SUPER-CHIP 128x64 screen, scaled to fill the window. Fading and pixel
+// gaps only apply to the low resolution screen.
+func (ppu *PPU) DrawHiRes(gfx *[64][128]byte) {
+	// The overlay can't redraw a high resolution frame, so it waits for the next one
+	ppu.last = nil
+
+	for i := 0; i < 64; i++ {
+		for j := 0; j < 128; j++ {
+			c := ppu.palette.pixel(gfx[i][j])
+
+			offset := (i*128 + j) * 4
+			ppu.hiPixels[offset] = c.B
+			ppu.hiPixels[offset+1] = c.G
+			ppu.hiPixels[offset+2] = c.R
+			ppu.hiPixels[offset+3] = c.A
+		}
+	}
+
+	ppu.hiTexture.Update(nil, ppu.hiPixels, 128*4)
+	ppu.renderer.Copy(ppu.hiTexture, nil, nil)
+	ppu.present()
+}
+
+func (ppu *PPU) drawFaded(gfx *[32][64]byte) {
+	ppu.fade.update(gfx)
+
+	for i := 0; i < 32; i++ {
+		for j := 0; j < 64; j++ {
+			// Pixels fade out from the plane 0 colour, as the fade doesn't know which planes were lit
+			bg, fg := ppu.palette[0], ppu.palette[1]
+			if value := gfx[i][j] & 0x3; value != 0 {
+				fg = ppu.palette[value]
 			}
 
-			ppu.renderer.DrawPoint(j, i)
+			intensity := ppu.fade.intensity[i][j]
+			ppu.renderer.SetDrawColor(blend(bg.R, fg.R, intensity), blend(bg.G, fg.G, intensity), blend(bg.B, fg.B, intensity), 255)
+			ppu.drawPixel(i, j)
 		}
 	}
 
+	ppu.present()
+}
+
+func (ppu *PPU) present() {
+	ppu.renderer.SetScale(float32(ppu.pixelScale()), float32(ppu.pixelScale()))
+
+	if ppu.overlay {
+		ppu.drawOverlay()
+	}
+
 	ppu.renderer.Present()
 }
 
+// Rewinding reports whether Backspace is held, which steps back through the frames kept by
+// Chip8.SetRewind.
+func (ppu *PPU) Rewinding() bool {
+	return ppu.rewinding
+}
+
 func (ppu *PPU) Poll(key *[16]bool) bool {
 	for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
-		switch eventType := event.(type) {
-		case *sdl.QuitEvent:
+		if exit := ppu.handleEvent(event, key); exit {
 			return true
+		}
+	}
 
-		case *sdl.KeyUpEvent:
-			if unpressed, ok := ppu.keypad[eventType.Keysym.Scancode]; ok {
-				key[unpressed] = false
-			}
+	return false
+}
 
-		case *sdl.KeyDownEvent:
-			if pressed, ok := ppu.keypad[eventType.Keysym.Scancode]; ok {
-				key[pressed] = true
-			}
+// handleEvent applies a single SDL event to key and the PPU's controls, reporting whether to quit.
+func (ppu *PPU) handleEvent(event sdl.Event, key *[16]bool) bool {
+	switch eventType := event.(type) {
+	case *sdl.QuitEvent:
+		return true
+
+	case *sdl.KeyUpEvent:
+		if eventType.Keysym.Scancode == sdl.SCANCODE_BACKSPACE {
+			ppu.rewinding = false
 		}
 
+		if unpressed, ok := ppu.keypad[eventType.Keysym.Scancode]; ok {
+			key[unpressed] = false
+		}
+
+	case *sdl.KeyDownEvent:
+		if eventType.Keysym.Scancode == sdl.SCANCODE_F3 && eventType.Repeat == 0 {
+			ppu.overlay = !ppu.overlay
+		}
+
+		if eventType.Keysym.Scancode == sdl.SCANCODE_BACKSPACE {
+			ppu.rewinding = true
+		}
+
+		if pressed, ok := ppu.keypad[eventType.Keysym.Scancode]; ok {
+			key[pressed] = true
+		}
 	}
 
 	return false
-}
\ No newline at end of file
+}
@@ -0,0 +1,105 @@
+package CHIP8
+
+import (
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestPixelRect(t *testing.T) {
+	cases := []struct {
+		row, col, scale, gap int
+		expected             sdl.Rect
+	}{
+		{0, 0, 10, 0, sdl.Rect{X: 0, Y: 0, W: 10, H: 10}},
+		{2, 3, 10, 0, sdl.Rect{X: 30, Y: 20, W: 10, H: 10}},
+		{2, 3, 10, 2, sdl.Rect{X: 31, Y: 21, W: 8, H: 8}},
+		{31, 63, 10, 3, sdl.Rect{X: 631, Y: 311, W: 7, H: 7}},
+		{1, 1, 4, 1, sdl.Rect{X: 4, Y: 4, W: 3, H: 3}},
+	}
+
+	for _, c := range cases {
+		if rect := pixelRect(c.row, c.col, c.scale, c.gap); rect != c.expected {
+			t.Errorf("TestPixelRect: unexpected rect for (%d, %d) at scale %d with gap %d. Expected: %+v Received: %+v",
+				c.row, c.col, c.scale, c.gap, c.expected, rect)
+		}
+	}
+
+	// Gaps are limited so pixels never vanish
+	ppu := &PPU{}
+	if ppu.SetPixelGap(50); ppu.gap != defaultScale-1 {
+		t.Errorf("TestPixelRect: failed to limit the gap. Expected: %d Received: %d", defaultScale-1, ppu.gap)
+	}
+
+	if ppu.SetPixelGap(-1); ppu.gap != 0 {
+		t.Errorf("TestPixelRect: failed to limit the gap. Expected: %d Received: %d", 0, ppu.gap)
+	}
+}
+
+func TestPPUScale(t *testing.T) {
+	cases := []struct {
+		scale, width, height int
+	}{
+		{1, 64, 32},
+		{3, 192, 96},
+		{10, 640, 320},
+		{15, 960, 480},
+	}
+
+	for _, c := range cases {
+		if width, height := windowSize(c.scale); width != c.width || height != c.height {
+			t.Errorf("TestPPUScale: unexpected window size at scale %d. Expected: %dx%d Received: %dx%d", c.scale, c.width, c.height, width, height)
+		}
+	}
+
+	ppu := &PPU{}
+	if ppu.pixelScale() != defaultScale {
+		t.Errorf("TestPPUScale: unexpected default scale. Expected: %d Received: %d", defaultScale, ppu.pixelScale())
+	}
+
+	if ppu.SetScale(0); ppu.pixelScale() != 1 {
+		t.Errorf("TestPPUScale: failed to limit the scale. Expected: %d Received: %d", 1, ppu.pixelScale())
+	}
+
+	// Shrinking the window narrows a gap that no longer fits
+	ppu.SetScale(10)
+	ppu.SetPixelGap(6)
+	if ppu.SetScale(5); ppu.gap != 4 {
+		t.Errorf("TestPPUScale: failed to narrow the gap. Expected: %d Received: %d", 4, ppu.gap)
+	}
+}
+
+func TestPPUKeymap(t *testing.T) {
+	ppu := &PPU{}
+	ppu.SetKeymap(DefaultKeymap)
+
+	var key [16]bool
+	ppu.handleEvent(&sdl.KeyDownEvent{Keysym: sdl.Keysym{Scancode: sdl.SCANCODE_X}}, &key)
+	if !key[0x0] {
+		t.Errorf("TestPPUKeymap: failed to press 0 with the default keymap")
+	}
+
+	// Remap 0 to J, dropping every other key
+	ppu.SetKeymap(map[sdl.Scancode]byte{sdl.SCANCODE_J: 0x0})
+
+	key = [16]bool{}
+	ppu.handleEvent(&sdl.KeyDownEvent{Keysym: sdl.Keysym{Scancode: sdl.SCANCODE_X}}, &key)
+	if key != [16]bool{} {
+		t.Errorf("TestPPUKeymap: pressed a key for an unmapped scancode. Received: %v", key)
+	}
+
+	ppu.handleEvent(&sdl.KeyDownEvent{Keysym: sdl.Keysym{Scancode: sdl.SCANCODE_J}}, &key)
+	if !key[0x0] {
+		t.Errorf("TestPPUKeymap: failed to press 0 with J")
+	}
+
+	ppu.handleEvent(&sdl.KeyUpEvent{Keysym: sdl.Keysym{Scancode: sdl.SCANCODE_J}}, &key)
+	if key[0x0] {
+		t.Errorf("TestPPUKeymap: failed to release 0 with J")
+	}
+
+	// The default is left as it was
+	if DefaultKeymap[sdl.SCANCODE_X] != 0x0 || len(DefaultKeymap) != 16 {
+		t.Errorf("TestPPUKeymap: changed the default keymap")
+	}
+}
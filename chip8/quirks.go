@@ -0,0 +1,80 @@
+package CHIP8
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Quirks select between the behaviours of different CHIP-8 interpreters for instructions
+// that weren't implemented consistently across platforms. The zero value matches the
+// behaviour most modern ROMs expect.
+type Quirks struct {
+	// Fx1E sets VF to 1 when I + Vx overflows past 0x0FFF, and to 0 otherwise.
+	// Spacefight 2091! relies on this.
+	AddIOverflowSetsVF bool
+
+	// 8xy6 and 8xyE shift Vy and store the result in Vx, rather than shifting Vx in place.
+	ShiftUsesVY bool
+
+	// Fx55 and Fx65 leave I set to I + x + 1, rather than leaving it unchanged.
+	LoadStoreIncrementsI bool
+
+	// Bnnn is read as Bxnn and jumps to xnn + Vx, rather than nnn + V0.
+	JumpUsesVX bool
+
+	// 8xy1, 8xy2 and 8xy3 reset VF to 0.
+	LogicResetsVF bool
+
+	// Dxyn clips sprites at the edges of the screen, rather than wrapping them around.
+	// The starting position wraps either way.
+	ClipSprites bool
+
+	// Dxyn waits for the vertical blank, so at most one sprite is drawn per frame.
+	DisplayWait bool
+
+	// RAM is 64KB rather than 4KB, and ROMs up to 64KB load. Only I, sequential execution and
+	// Bnnn offsets go past 4KB, as 1nnn and 2nnn can't encode more than 12 bits. XO-CHIP needs this.
+	ExtendedMemory bool
+}
+
+// Quirk profiles for the common platforms:
+//
+//	chip8:  the original COSMAC VIP interpreter. Shifts Vy, increments I on load/store,
+//	        resets VF on logic instructions, clips sprites and waits for the vertical blank to draw.
+//	schip:  SUPER-CHIP 1.1 on the HP48. Jumps with Bxnn and clips sprites.
+//	xochip: XO-CHIP. Shifts Vy and increments I on load/store, wraps sprites and has 64KB of RAM.
+var quirkProfiles = map[string]Quirks{
+	"chip8": {
+		ShiftUsesVY:          true,
+		LoadStoreIncrementsI: true,
+		LogicResetsVF:        true,
+		ClipSprites:          true,
+		DisplayWait:          true,
+	},
+	"schip": {
+		JumpUsesVX:  true,
+		ClipSprites: true,
+	},
+	"xochip": {
+		ShiftUsesVY:          true,
+		LoadStoreIncrementsI: true,
+		ExtendedMemory:       true,
+	},
+}
+
+// QuirksProfile returns the quirks of the named platform: chip8, schip or xochip.
+func QuirksProfile(name string) (Quirks, error) {
+	quirks, ok := quirkProfiles[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(quirkProfiles))
+		for profile := range quirkProfiles {
+			names = append(names, profile)
+		}
+		sort.Strings(names)
+
+		return Quirks{}, fmt.Errorf("unknown quirks profile %q, expected one of: %s", name, strings.Join(names, ", "))
+	}
+
+	return quirks, nil
+}
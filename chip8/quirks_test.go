@@ -0,0 +1,127 @@
+package CHIP8
+
+import (
+	"testing"
+)
+
+func TestQuirksProfile(t *testing.T) {
+	expected := map[string]Quirks{
+		"chip8":  {ShiftUsesVY: true, LoadStoreIncrementsI: true, LogicResetsVF: true, ClipSprites: true, DisplayWait: true},
+		"schip":  {JumpUsesVX: true, ClipSprites: true},
+		"xochip": {ShiftUsesVY: true, LoadStoreIncrementsI: true, ExtendedMemory: true},
+		"SCHIP":  {JumpUsesVX: true, ClipSprites: true},
+	}
+
+	for name, quirks := range expected {
+		received, err := QuirksProfile(name)
+		if err != nil {
+			t.Errorf("TestQuirksProfile: failed to look up %q: %v", name, err)
+		}
+
+		if received != quirks {
+			t.Errorf("TestQuirksProfile: unexpected quirks for %q. Expected: %+v Received: %+v", name, quirks, received)
+		}
+	}
+
+	if _, err := QuirksProfile("megachip"); err == nil {
+		t.Errorf("TestQuirksProfile: expected an error for an unknown profile")
+	}
+}
+
+func TestQuirks(t *testing.T) {
+	cases := []struct {
+		name   string
+		quirks Quirks
+		rom    []byte
+		check  func(cpu *CPU) bool
+	}{
+		{"shift Vx", Quirks{}, []byte{0x60, 0x03, 0x61, 0x80, 0x80, 0x16},
+			func(cpu *CPU) bool { return cpu.V[0x0] == 0x01 && cpu.V[0xF] == 1 }},
+		{"shift Vy", Quirks{ShiftUsesVY: true}, []byte{0x60, 0x03, 0x61, 0x80, 0x80, 0x1E},
+			func(cpu *CPU) bool { return cpu.V[0x0] == 0x00 && cpu.V[0xF] == 1 }},
+		{"shift Vx left", Quirks{}, []byte{0x60, 0x81, 0x61, 0x02, 0x80, 0x1E},
+			func(cpu *CPU) bool { return cpu.V[0x0] == 0x02 && cpu.V[0x1] == 0x02 && cpu.V[0xF] == 1 }},
+		{"shift Vy right", Quirks{ShiftUsesVY: true}, []byte{0x60, 0x02, 0x61, 0x07, 0x80, 0x16},
+			func(cpu *CPU) bool { return cpu.V[0x0] == 0x03 && cpu.V[0x1] == 0x07 && cpu.V[0xF] == 1 }},
+		{"load/store keeps I", Quirks{}, []byte{0xA3, 0x00, 0xF2, 0x55},
+			func(cpu *CPU) bool { return cpu.I == 0x300 }},
+		{"load keeps I", Quirks{}, []byte{0xA0, 0x05, 0xF2, 0x65},
+			func(cpu *CPU) bool { return cpu.I == 0x005 && cpu.V[0x0] == 0x20 && cpu.V[0x2] == 0x20 }},
+		{"load/store increments I", Quirks{LoadStoreIncrementsI: true}, []byte{0xA3, 0x00, 0xF2, 0x65},
+			func(cpu *CPU) bool { return cpu.I == 0x303 }},
+		{"store increments I", Quirks{LoadStoreIncrementsI: true}, []byte{0x62, 0x07, 0xA3, 0x00, 0xF2, 0x55},
+			func(cpu *CPU) bool { return cpu.I == 0x303 && cpu.RAM[0x302] == 0x07 && cpu.RAM[0x303] == 0x00 }},
+		{"jump V0", Quirks{}, []byte{0x60, 0x02, 0x62, 0x04, 0xB2, 0x10},
+			func(cpu *CPU) bool { return cpu.PC == 0x212 }},
+		{"jump Vx", Quirks{JumpUsesVX: true}, []byte{0x60, 0x02, 0x62, 0x04, 0xB2, 0x10},
+			func(cpu *CPU) bool { return cpu.PC == 0x214 }},
+		{"logic keeps VF", Quirks{}, []byte{0x6F, 0x05, 0x80, 0x11},
+			func(cpu *CPU) bool { return cpu.V[0xF] == 0x05 }},
+		{"logic resets VF", Quirks{LogicResetsVF: true}, []byte{0x6F, 0x05, 0x80, 0x13},
+			func(cpu *CPU) bool { return cpu.V[0xF] == 0x00 }},
+		// Draw font digit 0 at (62, 30)
+		{"wrap sprites", Quirks{}, []byte{0x60, 0x3E, 0x61, 0x1E, 0xA0, 0x00, 0xD0, 0x15},
+			func(cpu *CPU) bool { return cpu.GFX[30][0] == 1 && cpu.GFX[0][1] == 1 }},
+		{"clip sprites", Quirks{ClipSprites: true}, []byte{0x60, 0x3E, 0x61, 0x1E, 0xA0, 0x00, 0xD0, 0x15},
+			func(cpu *CPU) bool { return cpu.GFX[30][0] == 0 && cpu.GFX[0][1] == 0 && cpu.GFX[30][62] == 1 }},
+	}
+
+	for _, c := range cases {
+		cpu := &CPU{}
+		cpu.Init()
+		cpu.Quirks = c.quirks
+		cpu.PC = 0x200
+		copy(cpu.RAM[0x200:], c.rom)
+
+		for i := 0; i < len(c.rom)/2; i++ {
+			if err := cpu.Cycle(); err != nil {
+				t.Fatalf("TestQuirks: %s: cycle failed: %v", c.name, err)
+			}
+		}
+
+		if !c.check(cpu) {
+			t.Errorf("TestQuirks: %s: unexpected state. PC: %X I: %X V: %v", c.name, cpu.PC, cpu.I, cpu.V)
+		}
+	}
+}
+
+func TestQuirksExtendedMemory(t *testing.T) {
+	quirks, err := QuirksProfile("xochip")
+	if err != nil {
+		t.Fatalf("TestQuirksExtendedMemory: failed to look up xochip: %v", err)
+	}
+
+	chip8 := newTestChip8(&fakeDisplay{})
+	chip8.SetQuirks(quirks)
+
+	// 8KB doesn't fit in classic memory
+	rom := make([]byte, 0x2000)
+	copy(rom, []byte{
+		0x1F, 0xFE, // 200: jump FFE
+	})
+	copy(rom[0xFFE-0x200:], []byte{
+		0x60, 0x20, // FFE: V0 = 0x20, then run on past the top of classic memory
+		0xBF, 0xF0, // 1000: jump FF0 + V0
+	})
+	copy(rom[0x1010-0x200:], []byte{
+		0x60, 0x2A, // 1010: V0 = 0x2A
+		0xAF, 0xF0, // 1012: I = 0xFF0
+		0xF0, 0x1E, // 1014: I += V0
+	})
+
+	if err := chip8.cpu.LoadROMBytes(rom); err != nil {
+		t.Fatalf("TestQuirksExtendedMemory: failed to load an 8KB ROM: %v", err)
+	}
+
+	chip8.runFrame(6)
+
+	if chip8.cpu.V[0x0] != 0x2A || chip8.cpu.PC != 0x1016 || chip8.cpu.I != 0x101A {
+		t.Errorf("TestQuirksExtendedMemory: failed to run above 0x1000. PC: %X V0: %X I: %X", chip8.cpu.PC, chip8.cpu.V[0x0], chip8.cpu.I)
+	}
+
+	// Classic quirks go back to 4KB
+	chip8.SetQuirks(Quirks{})
+	if chip8.cpu.MemorySize() != 0x1000 {
+		t.Errorf("TestQuirksExtendedMemory: unexpected classic memory size. Expected: %d Received: %d", 0x1000, chip8.cpu.MemorySize())
+	}
+}
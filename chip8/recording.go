@@ -0,0 +1,81 @@
+package CHIP8
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrNotRecording is returned by StopRecording when no recording was started.
+var ErrNotRecording = errors.New("not recording")
+
+// gifRecording is a GIF being recorded by the run loop, see StartRecording.
+type gifRecording struct {
+	recorder *GIFRecorder
+	file     *os.File
+}
+
+// StartRecording records the screens drawn from now on as an animated GIF, written to path by
+// StopRecording. At most limit screens are kept, so memory stays bounded; limit 0 records until
+// stopped. Each screen is shown for delay 1/100s, or for as long as it was on screen if delay
+// is 0. A recording already running is stopped first.
+func (chip8 *Chip8) StartRecording(path string, limit int, delay int) error {
+	if chip8.gif != nil {
+		if err := chip8.StopRecording(); err != nil {
+			return err
+		}
+	}
+
+	// Create the file now, so a bad path is reported before anything is recorded
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("start recording: %v", err)
+	}
+
+	recorder := NewGIFRecorder(4)
+	recorder.Limit = limit
+	recorder.Delay = delay
+
+	// Start from the screen as it is, which may not be redrawn for a while
+	chip8.gif = &gifRecording{recorder: recorder, file: file}
+	chip8.recordScreen()
+
+	return nil
+}
+
+// StopRecording writes the recording started by StartRecording and closes its file.
+func (chip8 *Chip8) StopRecording() error {
+	if chip8.gif == nil {
+		return fmt.Errorf("stop recording: %w", ErrNotRecording)
+	}
+
+	recording := chip8.gif
+	chip8.gif = nil
+
+	if err := recording.recorder.Encode(recording.file, chip8.frame); err != nil {
+		recording.file.Close()
+		return fmt.Errorf("stop recording: %v", err)
+	}
+
+	if err := recording.file.Close(); err != nil {
+		return fmt.Errorf("stop recording: %v", err)
+	}
+
+	return nil
+}
+
+// recordScreen adds the screen to the recording, if there is one. The high resolution screen is
+// halved to fit.
+func (chip8 *Chip8) recordScreen() {
+	if chip8.gif == nil {
+		return
+	}
+
+	gfx := &chip8.cpu.GFX
+	if chip8.cpu.HiRes {
+		low := downscale(&chip8.cpu.HiGFX)
+		gfx = &low
+	}
+
+	chip8.gif.recorder.Add(chip8.frame, gfx)
+}
@@ -0,0 +1,73 @@
+package CHIP8
+
+import (
+	"errors"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// decodeGIF decodes the animated GIF at path.
+func decodeGIF(t *testing.T, path string) *gif.GIF {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	anim, err := gif.DecodeAll(file)
+	if err != nil {
+		t.Fatalf("failed to decode %s: %v", path, err)
+	}
+
+	return anim
+}
+
+func TestRecording(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clip.gif")
+
+	chip8 := newTestChip8(&fakeDisplay{})
+	copy(chip8.cpu.RAM[0x200:], []byte{
+		0x00, 0xE0, // 200: clear the screen, which draws every frame
+		0x12, 0x00, // 202: jump 200
+	})
+
+	if err := chip8.StartRecording(path, 0, 0); err != nil {
+		t.Fatalf("TestRecording: failed to start: %v", err)
+	}
+
+	// The screen at the start and the one drawn in the same frame are one image
+	for frame := 0; frame < 4; frame++ {
+		chip8.runFrame(4)
+	}
+
+	if err := chip8.StopRecording(); err != nil {
+		t.Fatalf("TestRecording: failed to stop: %v", err)
+	}
+
+	if anim := decodeGIF(t, path); len(anim.Image) != 4 {
+		t.Errorf("TestRecording: unexpected frame count. Expected: %d Received: %d", 4, len(anim.Image))
+	}
+
+	// Frames past the limit are dropped
+	if err := chip8.StartRecording(path, 2, 5); err != nil {
+		t.Fatalf("TestRecording: failed to start with a limit: %v", err)
+	}
+
+	for frame := 0; frame < 4; frame++ {
+		chip8.runFrame(4)
+	}
+
+	if err := chip8.StopRecording(); err != nil {
+		t.Fatalf("TestRecording: failed to stop with a limit: %v", err)
+	}
+
+	if anim := decodeGIF(t, path); len(anim.Image) != 2 || anim.Delay[0] != 5 || anim.Delay[1] != 5 {
+		t.Errorf("TestRecording: unexpected limited recording. Frames: %d Delays: %v", len(anim.Image), anim.Delay)
+	}
+
+	if err := chip8.StopRecording(); !errors.Is(err, ErrNotRecording) {
+		t.Errorf("TestRecording: expected ErrNotRecording when stopping twice. Received: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package CHIP8
+
+// rewindBuffer is a ring of the states at the end of recent frames. Its memory is allocated
+// up front, so it doesn't grow however long the emulator runs.
+type rewindBuffer struct {
+	states []saveState
+	next   int // Index the next state is stored at
+	count  int // Number of states held, up to len(states)
+}
+
+func newRewindBuffer(depth int) *rewindBuffer {
+	return &rewindBuffer{states: make([]saveState, depth)}
+}
+
+// push stores the CPU state, replacing the oldest one if the ring is full.
+func (buffer *rewindBuffer) push(cpu *CPU) {
+	cpu.storeState(&buffer.states[buffer.next])
+
+	buffer.next = (buffer.next + 1) % len(buffer.states)
+	if buffer.count < len(buffer.states) {
+		buffer.count++
+	}
+}
+
+// rewind restores the CPU to the state frames before the latest one, or the oldest held,
+// dropping the states after it. It returns the number of frames stepped back.
+func (buffer *rewindBuffer) rewind(cpu *CPU, frames int) int {
+	if frames > buffer.count-1 {
+		frames = buffer.count - 1
+	}
+
+	if frames <= 0 {
+		return 0
+	}
+
+	buffer.count -= frames
+	buffer.next = (buffer.next - frames + len(buffer.states)) % len(buffer.states)
+
+	latest := (buffer.next - 1 + len(buffer.states)) % len(buffer.states)
+	cpu.restoreState(&buffer.states[latest])
+
+	return frames
+}
+
+// SetRewind keeps the state at the end of each of the last frames frames so Rewind can step
+// back to them. Each frame takes about 70KB. 0 turns rewinding off.
+func (chip8 *Chip8) SetRewind(frames int) {
+	if frames <= 0 {
+		chip8.rewind = nil
+		return
+	}
+
+	chip8.rewind = newRewindBuffer(frames)
+}
+
+// Rewind steps back the given number of frames, as far as SetRewind allows, and returns how
+// many it went back. The keys being held are kept, so live input carries on.
+func (chip8 *Chip8) Rewind(frames int) int {
+	if chip8.rewind == nil {
+		return 0
+	}
+
+	key := chip8.cpu.Key
+	frames = chip8.rewind.rewind(chip8.cpu, frames)
+	chip8.cpu.Key = key
+
+	return frames
+}
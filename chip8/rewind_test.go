@@ -0,0 +1,92 @@
+package CHIP8
+
+import (
+	"testing"
+)
+
+// newRewindChip8 builds a Chip8 that adds 1 to V0 every instruction, so each frame's state differs.
+func newRewindChip8(display *fakeDisplay, depth int) *Chip8 {
+	chip8 := newTestChip8(display)
+	copy(chip8.cpu.RAM[0x200:], []byte{
+		0x70, 0x01, // 200: V0 += 1
+		0x12, 0x00, // 202: jump 200
+	})
+	chip8.SetRewind(depth)
+
+	return chip8
+}
+
+func TestRewind(t *testing.T) {
+	chip8 := newRewindChip8(&fakeDisplay{}, 5)
+	var recorded saveState
+
+	for frame := 1; frame <= 10; frame++ {
+		chip8.runFrame(4)
+
+		if frame == 7 {
+			chip8.cpu.storeState(&recorded)
+		}
+	}
+
+	if frames := chip8.Rewind(3); frames != 3 {
+		t.Fatalf("TestRewind: unexpected number of frames rewound. Expected: %d Received: %d", 3, frames)
+	}
+
+	var rewound saveState
+	chip8.cpu.storeState(&rewound)
+
+	if rewound != recorded {
+		t.Errorf("TestRewind: state doesn't match frame 7. Expected V0: %d PC: %d Received V0: %d PC: %d",
+			recorded.V[0], recorded.PC, rewound.V[0], rewound.PC)
+	}
+
+	// Only the last 5 frames were kept, 6 to 10, so frame 6 is as far back as it goes
+	if frames := chip8.Rewind(100); frames != 1 {
+		t.Errorf("TestRewind: rewound past the oldest frame kept. Expected: %d Received: %d", 1, frames)
+	}
+
+	if chip8.cpu.V[0] != 12 {
+		t.Errorf("TestRewind: unexpected V0 at frame 6. Expected: %d Received: %d", 12, chip8.cpu.V[0])
+	}
+
+	if frames := chip8.Rewind(1); frames != 0 {
+		t.Errorf("TestRewind: rewound with no earlier frames. Received: %d", frames)
+	}
+
+	// Emulation carries on from the restored frame
+	chip8.runFrame(4)
+	if chip8.cpu.V[0] != 14 {
+		t.Errorf("TestRewind: failed to resume from the restored frame. Expected V0: %d Received: %d", 14, chip8.cpu.V[0])
+	}
+}
+
+// rewindingDisplay is a fakeDisplay that asks to rewind.
+type rewindingDisplay struct {
+	fakeDisplay
+	rewinding bool
+}
+
+func (display *rewindingDisplay) Rewinding() bool {
+	return display.rewinding
+}
+
+func TestRewindDisplay(t *testing.T) {
+	display := &rewindingDisplay{}
+	chip8 := newRewindChip8(&display.fakeDisplay, 600)
+	chip8.ppu = display
+
+	for i := 0; i < 4; i++ {
+		chip8.runFrame(2)
+	}
+
+	display.rewinding = true
+	chip8.runFrame(2)
+
+	if chip8.cpu.V[0] != 3 {
+		t.Errorf("TestRewindDisplay: failed to step back a frame. Expected V0: %d Received: %d", 3, chip8.cpu.V[0])
+	}
+
+	if display.calls[len(display.calls)-2] != "draw" {
+		t.Errorf("TestRewindDisplay: failed to draw the restored frame. Received: %v", display.calls)
+	}
+}
@@ -0,0 +1,30 @@
+package CHIP8
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+)
+
+// knownROM is a ROM that has been checked to run correctly with a set of quirks.
+type knownROM struct {
+	Name   string
+	Quirks Quirks
+}
+
+// knownROMs maps the hex SHA-1 of a ROM's bytes to the quirks it was verified against.
+// Only add ROMs after checking them against the platform they were written for, hashing the
+// exact file that was checked.
+var knownROMs = map[string]knownROM{
+	// The bundled ROMs, see builtin.go, need no quirks
+	"31da2d3c2cf37afc3f28d8afab6b5f98f63ca8e9": {Name: "counter"},
+	"9250aba1b73fda3e3fbad24dc7e921fede8a1a92": {Name: "digits"},
+	"5e9879b63e7cafdb1f53bab52714563a89f29ebf": {Name: "keypad"},
+}
+
+// lookupROM finds the entry for rom in the known ROM table.
+func lookupROM(rom []byte) (knownROM, bool) {
+	sum := sha1.Sum(rom)
+	known, ok := knownROMs[hex.EncodeToString(sum[:])]
+
+	return known, ok
+}
@@ -0,0 +1,99 @@
+package CHIP8
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// loadKnownROM writes rom to a file, registers quirks for it in the known ROM table and loads
+// it into a Chip8, calling before between creating the Chip8 and loading the ROM.
+func loadKnownROM(t *testing.T, rom []byte, quirks Quirks, before func(chip8 *Chip8)) *Chip8 {
+	dir, err := ioutil.TempDir("", "romdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "known.ch8")
+	if err := ioutil.WriteFile(filename, rom, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha1.Sum(rom)
+	hash := hex.EncodeToString(sum[:])
+	knownROMs[hash] = knownROM{Name: "Known", Quirks: quirks}
+	defer delete(knownROMs, hash)
+
+	chip8 := newTestChip8(&fakeDisplay{})
+	before(chip8)
+
+	if err := chip8.Load(&filename); err != nil {
+		t.Fatalf("loadKnownROM: failed to load ROM: %v", err)
+	}
+
+	return chip8
+}
+
+func TestLoadDetectsKnownROM(t *testing.T) {
+	rom := []byte{0xF0, 0x1E, 0x12, 0x00}
+	quirks := Quirks{AddIOverflowSetsVF: true}
+
+	chip8 := loadKnownROM(t, rom, quirks, func(chip8 *Chip8) {})
+	if chip8.cpu.Quirks != quirks {
+		t.Errorf("TestLoadDetectsKnownROM: failed to apply detected quirks. Expected: %+v Received: %+v", quirks, chip8.cpu.Quirks)
+	}
+
+	// Quirks chosen by the user win
+	chip8 = loadKnownROM(t, rom, quirks, func(chip8 *Chip8) {
+		chip8.SetQuirks(Quirks{})
+	})
+	if chip8.cpu.Quirks != (Quirks{}) {
+		t.Errorf("TestLoadDetectsKnownROM: detected quirks overrode the user's. Expected: %+v Received: %+v", Quirks{}, chip8.cpu.Quirks)
+	}
+
+	// Unknown ROMs keep the defaults
+	if _, ok := lookupROM(append(rom, 0x00)); ok {
+		t.Errorf("TestLoadDetectsKnownROM: matched a ROM that isn't in the table")
+	}
+}
+
+func TestKnownROMs(t *testing.T) {
+	for hash, known := range knownROMs {
+		// lookupROM hashes to lowercase hex, so anything else never matches
+		if sum, err := hex.DecodeString(hash); err != nil || len(sum) != sha1.Size || hex.EncodeToString(sum) != hash {
+			t.Errorf("TestKnownROMs: %s isn't a lowercase hex SHA-1: %s", known.Name, hash)
+		}
+
+		if known.Name == "" {
+			t.Errorf("TestKnownROMs: %s has no name", hash)
+		}
+	}
+
+	// Every bundled ROM is in the table
+	for _, name := range Builtins() {
+		rom, err := BuiltinROM(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if known, ok := lookupROM(rom); !ok || known.Name != name || known.Quirks != (Quirks{}) {
+			t.Errorf("TestKnownROMs: failed to find %s. Received: %+v", name, known)
+		}
+	}
+}
+
+func TestLoadDetectsMemorySize(t *testing.T) {
+	// Detected quirks resize the address space like SetQuirks, wrapping I to fit
+	chip8 := loadKnownROM(t, []byte{0xA2, 0x00, 0x12, 0x02}, Quirks{}, func(chip8 *Chip8) {
+		chip8.cpu.SetExtendedMemory(true)
+		chip8.cpu.I = 0x1234
+	})
+
+	if chip8.cpu.MemorySize() != 0x1000 || chip8.cpu.I != 0x234 {
+		t.Errorf("TestLoadDetectsMemorySize: failed to switch to classic memory. Size: %d I: %X", chip8.cpu.MemorySize(), chip8.cpu.I)
+	}
+}
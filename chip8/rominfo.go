@@ -0,0 +1,102 @@
+package CHIP8
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// Platforms a ROM can be written for, from the most to the least widely supported.
+const (
+	PlatformCHIP8  = "chip8"
+	PlatformSCHIP  = "schip"
+	PlatformXOCHIP = "xochip"
+)
+
+// ROMInfo summarises a ROM without running it.
+type ROMInfo struct {
+	Size     int    // Length of the ROM in bytes
+	MaxSize  int    // Space for the ROM on its platform, from 0x200 to the end of RAM
+	Platform string // Best guess at the platform the ROM targets
+	SHA1     string // Hex SHA-1 of the ROM's bytes
+	Name     string // Name from the known ROM table, if the ROM is in it
+}
+
+// Fits reports whether the ROM fits in RAM on its platform.
+func (info ROMInfo) Fits() bool {
+	return info.Size <= info.MaxSize
+}
+
+// Print writes the summary to w, one field per line.
+func (info ROMInfo) Print(w io.Writer) {
+	fits := "fits"
+	if !info.Fits() {
+		fits = "too large"
+	}
+
+	fmt.Fprintf(w, "Size:     %d bytes (%s, %d available)\n", info.Size, fits, info.MaxSize)
+	fmt.Fprintf(w, "Platform: %s\n", info.Platform)
+	fmt.Fprintf(w, "SHA-1:    %s\n", info.SHA1)
+
+	if info.Name != "" {
+		fmt.Fprintf(w, "Known as: %s\n", info.Name)
+	}
+}
+
+// InspectROM scans rom's opcodes to guess its platform. Sprite data mixed in with the code is
+// scanned too, so the guess can be too high, but never too low.
+func InspectROM(rom []byte) ROMInfo {
+	sum := sha1.Sum(rom)
+	info := ROMInfo{Size: len(rom), Platform: PlatformCHIP8, SHA1: hex.EncodeToString(sum[:])}
+
+	if known, ok := lookupROM(rom); ok {
+		info.Name = known.Name
+	}
+
+	for i := 0; i+1 < len(rom); i += 2 {
+		switch opCodePlatform(uint16(rom[i])<<8 | uint16(rom[i+1])) {
+		case PlatformXOCHIP:
+			info.Platform = PlatformXOCHIP
+		case PlatformSCHIP:
+			if info.Platform == PlatformCHIP8 {
+				info.Platform = PlatformSCHIP
+			}
+		}
+	}
+
+	info.MaxSize = 0x1000 - 0x200
+	if info.Platform == PlatformXOCHIP {
+		info.MaxSize = 0x10000 - 0x200
+	}
+
+	return info
+}
+
+// opCodePlatform returns the least capable platform that defines opCode.
+func opCodePlatform(opCode uint16) string {
+	switch {
+	case opCode&0xFFF0 == 0x00C0, // 00Cn: Scroll down n lines
+		opCode == 0x00FB,        // 00FB: Scroll right
+		opCode == 0x00FC,        // 00FC: Scroll left
+		opCode == 0x00FD,        // 00FD: Exit
+		opCode == 0x00FE,        // 00FE: Low resolution
+		opCode == 0x00FF,        // 00FF: High resolution
+		opCode&0xF00F == 0xD000, // Dxy0: Draw a 16x16 sprite
+		opCode&0xF0FF == 0xF030, // Fx30: Big font
+		opCode&0xF0FF == 0xF075, // Fx75: Save flags
+		opCode&0xF0FF == 0xF085: // Fx85: Load flags
+		return PlatformSCHIP
+
+	case opCode&0xFFF0 == 0x00D0, // 00Dn: Scroll up n lines
+		opCode&0xF00F == 0x5002, // 5xy2: Save Vx - Vy
+		opCode&0xF00F == 0x5003, // 5xy3: Load Vx - Vy
+		opCode == 0xF000,        // F000 nnnn: Load a 16-bit I
+		opCode&0xF0FF == 0xF001, // Fn01: Select bit planes
+		opCode == 0xF002,        // F002: Load audio pattern
+		opCode&0xF0FF == 0xF03A: // Fx3A: Set pitch
+		return PlatformXOCHIP
+	}
+
+	return PlatformCHIP8
+}
@@ -0,0 +1,39 @@
+package CHIP8
+
+import (
+	"testing"
+)
+
+func TestInspectROM(t *testing.T) {
+	cases := []struct {
+		name     string
+		rom      []byte
+		platform string
+	}{
+		{"chip8", []byte{0x60, 0x05, 0xA2, 0x0A, 0xD0, 0x15, 0x12, 0x06}, PlatformCHIP8},
+		{"schip hires", []byte{0x00, 0xFF, 0x60, 0x05, 0x12, 0x04}, PlatformSCHIP},
+		{"schip big sprite", []byte{0x60, 0x05, 0xD0, 0x10}, PlatformSCHIP},
+		{"xochip beats schip", []byte{0x00, 0xFF, 0xF0, 0x00, 0x12, 0x34}, PlatformXOCHIP},
+	}
+
+	for _, c := range cases {
+		info := InspectROM(c.rom)
+
+		if info.Platform != c.platform {
+			t.Errorf("TestInspectROM: %s: unexpected platform. Expected: %s Received: %s", c.name, c.platform, info.Platform)
+		}
+
+		if info.Size != len(c.rom) || !info.Fits() {
+			t.Errorf("TestInspectROM: %s: unexpected size. Expected: %d bytes, fitting Received: %+v", c.name, len(c.rom), info)
+		}
+	}
+
+	// SHA-1 of the empty ROM
+	if info := InspectROM(nil); info.SHA1 != "da39a3ee5e6b4b0d3255bfef95601890afd80709" {
+		t.Errorf("TestInspectROM: unexpected SHA-1. Received: %s", info.SHA1)
+	}
+
+	if info := InspectROM(make([]byte, 0x1000)); info.Fits() {
+		t.Errorf("TestInspectROM: a 4KB ROM doesn't fit after 0x200 in classic memory")
+	}
+}
@@ -0,0 +1,146 @@
+package CHIP8
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ErrSaveState is returned by Load for data that isn't a save state this version can restore.
+var ErrSaveState = errors.New("invalid save state")
+
+const saveStateVersion = 3
+
+var saveStateMagic = [4]byte{'C', '8', 'S', 'T'}
+
+// saveState is the layout of a save state after the magic and version, written big-endian.
+// The keypad mapping and SDL handles belong to the PPU and aren't part of it.
+type saveState struct {
+	RAM   [65536]byte
+	GFX   [32][64]byte
+	HiGFX [64][128]byte
+	HiRes bool
+	Stack [16]uint16
+	V     [16]byte
+
+	PC uint16
+	SP uint16
+	I  uint16
+
+	DT byte
+	ST byte
+
+	Key [16]bool
+
+	Pattern       [16]byte
+	Pitch         byte
+	PatternLoaded bool
+
+	RS             uint32
+	ExtendedMemory bool
+
+	Flags       [8]byte
+	FontBase    uint16
+	BigFontBase uint16
+}
+
+// Save serializes the machine state so Load can restore it later, e.g. to resume a game.
+func (cpu *CPU) Save() ([]byte, error) {
+	var state saveState
+	cpu.storeState(&state)
+
+	var buf bytes.Buffer
+	buf.Write(saveStateMagic[:])
+	buf.WriteByte(saveStateVersion)
+
+	if err := binary.Write(&buf, binary.BigEndian, &state); err != nil {
+		return nil, fmt.Errorf("save state: %v", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Load restores the machine state from data written by Save. The CPU is left untouched if
+// data is invalid.
+func (cpu *CPU) Load(data []byte) error {
+	if len(data) < len(saveStateMagic)+1 || !bytes.Equal(data[:len(saveStateMagic)], saveStateMagic[:]) {
+		return fmt.Errorf("%w: missing header", ErrSaveState)
+	}
+
+	if version := data[len(saveStateMagic)]; version != saveStateVersion {
+		return fmt.Errorf("%w: version %d, expected %d", ErrSaveState, version, saveStateVersion)
+	}
+
+	var state saveState
+	body := data[len(saveStateMagic)+1:]
+
+	if len(body) != binary.Size(&state) {
+		return fmt.Errorf("%w: %d bytes, expected %d", ErrSaveState, len(body), binary.Size(&state))
+	}
+
+	if err := binary.Read(bytes.NewReader(body), binary.BigEndian, &state); err != nil {
+		return fmt.Errorf("%w: %v", ErrSaveState, err)
+	}
+
+	if int(state.SP) > len(state.Stack) {
+		return fmt.Errorf("%w: stack pointer %d, expected at most %d", ErrSaveState, state.SP, len(state.Stack))
+	}
+
+	cpu.restoreState(&state)
+
+	return nil
+}
+
+// storeState copies the machine state into state.
+func (cpu *CPU) storeState(state *saveState) {
+	state.RAM = cpu.RAM
+	state.GFX = cpu.GFX
+	state.HiGFX = cpu.HiGFX
+	state.HiRes = cpu.HiRes
+	state.Stack = cpu.Stack
+	state.V = cpu.V
+	state.PC = cpu.PC
+	state.SP = cpu.SP
+	state.I = cpu.I
+	state.DT = cpu.DT
+	state.ST = cpu.ST
+	state.Key = cpu.Key
+	state.Pattern = cpu.Pattern
+	state.Pitch = cpu.Pitch
+	state.PatternLoaded = cpu.patternLoaded
+	state.RS = uint32(cpu.RS)
+	state.ExtendedMemory = cpu.Quirks.ExtendedMemory
+	state.Flags = cpu.Flags
+	state.FontBase = cpu.fontBase
+	state.BigFontBase = cpu.bigFontBase
+}
+
+// restoreState sets the machine state from state and redraws the screen.
+func (cpu *CPU) restoreState(state *saveState) {
+	cpu.RAM = state.RAM
+	cpu.GFX = state.GFX
+	cpu.HiGFX = state.HiGFX
+	cpu.HiRes = state.HiRes
+	cpu.Stack = state.Stack
+	cpu.V = state.V
+	cpu.PC = state.PC
+	cpu.SP = state.SP
+	cpu.I = state.I
+	cpu.DT = state.DT
+	cpu.ST = state.ST
+	cpu.Key = state.Key
+	cpu.Pattern = state.Pattern
+	cpu.Pitch = state.Pitch
+	cpu.patternLoaded = state.PatternLoaded
+	cpu.RS = int(state.RS)
+	cpu.Quirks.ExtendedMemory = state.ExtendedMemory
+	cpu.Flags = state.Flags
+	cpu.fontBase = state.FontBase
+	cpu.bigFontBase = state.BigFontBase
+
+	// Show the restored screen
+	cpu.vblankWait = false
+	cpu.dirty.markAll()
+	cpu.DF = true
+}
@@ -0,0 +1,135 @@
+package CHIP8
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSaveLoad(t *testing.T) {
+	cpu := &CPU{}
+	cpu.Init()
+	cpu.SetExtendedMemory(true)
+
+	for i := range cpu.RAM {
+		cpu.RAM[i] = byte(i * 7)
+	}
+	cpu.GFX[3][60] = 1
+	cpu.GFX[31][0] = 1
+	cpu.Stack[0] = 0x234
+	cpu.Stack[1] = 0xABC
+	cpu.V[0x3] = 0x42
+	cpu.V[0xF] = 1
+	cpu.PC = 0x3FE
+	cpu.SP = 2
+	cpu.I = 0xF123
+	cpu.DT = 30
+	cpu.ST = 5
+	cpu.Key[0xA] = true
+	cpu.Pattern[15] = 0x81
+	cpu.Pitch = 112
+	cpu.patternLoaded = true
+	cpu.RS = 1234
+	cpu.Flags[7] = 0x99
+	cpu.fontBase = 0x050
+	cpu.bigFontBase = 0x0A0
+
+	data, err := cpu.Save()
+	if err != nil {
+		t.Fatalf("TestSaveLoad: failed to save: %v", err)
+	}
+
+	saved := *cpu
+	*cpu = CPU{}
+
+	if err := cpu.Load(data); err != nil {
+		t.Fatalf("TestSaveLoad: failed to load: %v", err)
+	}
+
+	if cpu.RAM != saved.RAM {
+		t.Errorf("TestSaveLoad: RAM wasn't restored")
+	}
+
+	if cpu.GFX != saved.GFX {
+		t.Errorf("TestSaveLoad: GFX wasn't restored")
+	}
+
+	if cpu.Stack != saved.Stack {
+		t.Errorf("TestSaveLoad: unexpected stack. Expected: %v Received: %v", saved.Stack, cpu.Stack)
+	}
+
+	if cpu.V != saved.V {
+		t.Errorf("TestSaveLoad: unexpected registers. Expected: %v Received: %v", saved.V, cpu.V)
+	}
+
+	if cpu.PC != saved.PC || cpu.SP != saved.SP || cpu.I != saved.I {
+		t.Errorf("TestSaveLoad: unexpected PC, SP and I. Expected: %d %d %d Received: %d %d %d",
+			saved.PC, saved.SP, saved.I, cpu.PC, cpu.SP, cpu.I)
+	}
+
+	if cpu.DT != saved.DT || cpu.ST != saved.ST {
+		t.Errorf("TestSaveLoad: unexpected timers. Expected: %d %d Received: %d %d", saved.DT, saved.ST, cpu.DT, cpu.ST)
+	}
+
+	if cpu.Key != saved.Key {
+		t.Errorf("TestSaveLoad: unexpected keys. Expected: %v Received: %v", saved.Key, cpu.Key)
+	}
+
+	if cpu.Pattern != saved.Pattern || cpu.Pitch != saved.Pitch || !cpu.patternLoaded {
+		t.Errorf("TestSaveLoad: audio pattern wasn't restored. Pattern: %X Pitch: %d", cpu.Pattern, cpu.Pitch)
+	}
+
+	if cpu.RS != saved.RS || !cpu.Quirks.ExtendedMemory {
+		t.Errorf("TestSaveLoad: unexpected ROM size or memory size. Expected: %d Received: %d", saved.RS, cpu.RS)
+	}
+
+	if cpu.Flags != saved.Flags {
+		t.Errorf("TestSaveLoad: unexpected user flags. Expected: %v Received: %v", saved.Flags, cpu.Flags)
+	}
+
+	if cpu.fontBase != saved.fontBase || cpu.bigFontBase != saved.bigFontBase {
+		t.Errorf("TestSaveLoad: unexpected font addresses. Expected: %X %X Received: %X %X",
+			saved.fontBase, saved.bigFontBase, cpu.fontBase, cpu.bigFontBase)
+	}
+
+	if !cpu.DF {
+		t.Errorf("TestSaveLoad: failed to redraw the restored screen")
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	cpu := &CPU{}
+	cpu.Init()
+
+	data, err := cpu.Save()
+	if err != nil {
+		t.Fatalf("TestLoadInvalid: failed to save: %v", err)
+	}
+
+	wrongVersion := append([]byte{}, data...)
+	wrongVersion[4] = saveStateVersion + 1
+
+	cpu.SP = 199
+	badSP, err := cpu.Save()
+	if err != nil {
+		t.Fatalf("TestLoadInvalid: failed to save: %v", err)
+	}
+	cpu.SP = 0
+
+	for name, invalid := range map[string][]byte{
+		"empty":     nil,
+		"not saved": []byte("CHIP-8 ROM"),
+		"version":   wrongVersion,
+		"truncated": data[:len(data)-1],
+		"stack":     badSP,
+	} {
+		cpu.PC = 0x200
+
+		if err := cpu.Load(invalid); !errors.Is(err, ErrSaveState) {
+			t.Errorf("TestLoadInvalid: failed to reject %s data. Received: %v", name, err)
+		}
+
+		if cpu.PC != 0x200 || cpu.SP != 0 {
+			t.Errorf("TestLoadInvalid: %s data changed the CPU", name)
+		}
+	}
+}
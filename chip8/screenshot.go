@@ -0,0 +1,67 @@
+package CHIP8
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+)
+
+// FrameToImage draws a screen with each pixel as a scale x scale square, in the colours of
+// DefaultPalette: lit pixels white on black.
+func FrameToImage(gfx *[32][64]byte, scale int) *image.RGBA {
+	return scaledImage(64, 32, scale, func(x int, y int) byte { return gfx[y][x] })
+}
+
+// hiFrameToImage draws the SUPER-CHIP high resolution screen like FrameToImage.
+func hiFrameToImage(hi *[64][128]byte, scale int) *image.RGBA {
+	return scaledImage(128, 64, scale, func(x int, y int) byte { return hi[y][x] })
+}
+
+// scaledImage draws a width x height screen, looking up each pixel's value with pixel.
+func scaledImage(width int, height int, scale int, pixel func(x int, y int) byte) *image.RGBA {
+	if scale < 1 {
+		scale = 1
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width*scale, height*scale))
+
+	for i := 0; i < height; i++ {
+		for j := 0; j < width; j++ {
+			c := DefaultPalette.pixel(pixel(j, i))
+
+			for y := i * scale; y < (i+1)*scale; y++ {
+				for x := j * scale; x < (j+1)*scale; x++ {
+					img.SetRGBA(x, y, c)
+				}
+			}
+		}
+	}
+
+	return img
+}
+
+// Screenshot writes the screen to path as a PNG, with each pixel as a scale x scale square. In
+// SUPER-CHIP high resolution mode the image is twice as big, so no pixels are lost.
+func (chip8 *Chip8) Screenshot(path string, scale int) error {
+	img := FrameToImage(&chip8.cpu.GFX, scale)
+	if chip8.cpu.HiRes {
+		img = hiFrameToImage(&chip8.cpu.HiGFX, scale)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("screenshot: %v", err)
+	}
+
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return fmt.Errorf("screenshot: %v", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("screenshot: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package CHIP8
+
+import (
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var (
+	white = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	black = color.RGBA{A: 255}
+)
+
+func TestFrameToImage(t *testing.T) {
+	var gfx [32][64]byte
+	gfx[0][0] = 1
+	gfx[31][63] = 1
+	gfx[10][20] = 1
+
+	img := FrameToImage(&gfx, 3)
+	if bounds := img.Bounds(); bounds.Dx() != 192 || bounds.Dy() != 96 {
+		t.Fatalf("TestFrameToImage: unexpected image size. Expected: 192x96 Received: %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+	pixels := []struct {
+		x, y     int
+		expected color.RGBA
+	}{
+		{0, 0, white},
+		{2, 2, white},
+		{3, 0, black},
+		{0, 3, black},
+		{60, 30, white},
+		{62, 32, white},
+		{63, 30, black},
+		{189, 93, white},
+		{191, 95, white},
+		{188, 95, black},
+		{100, 50, black},
+	}
+
+	for _, pixel := range pixels {
+		if received := img.RGBAAt(pixel.x, pixel.y); received != pixel.expected {
+			t.Errorf("TestFrameToImage: unexpected colour at (%d, %d). Expected: %v Received: %v", pixel.x, pixel.y, pixel.expected, received)
+		}
+	}
+
+	if bounds := FrameToImage(&gfx, 0).Bounds(); bounds.Dx() != 64 || bounds.Dy() != 32 {
+		t.Errorf("TestFrameToImage: failed to draw at scale 1 for scale 0. Received: %dx%d", bounds.Dx(), bounds.Dy())
+	}
+}
+
+func TestScreenshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "screen.png")
+
+	chip8 := newTestChip8(&fakeDisplay{})
+	chip8.cpu.GFX[5][7] = 1
+
+	if err := chip8.Screenshot(path, 2); err != nil {
+		t.Fatalf("TestScreenshot: failed to save: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatalf("TestScreenshot: failed to decode: %v", err)
+	}
+
+	if bounds := img.Bounds(); bounds.Dx() != 128 || bounds.Dy() != 64 {
+		t.Fatalf("TestScreenshot: unexpected image size. Expected: 128x64 Received: %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+	if received := color.RGBAModel.Convert(img.At(15, 11)); received != white {
+		t.Errorf("TestScreenshot: unexpected colour of a lit pixel. Expected: %v Received: %v", white, received)
+	}
+
+	if received := color.RGBAModel.Convert(img.At(16, 11)); received != black {
+		t.Errorf("TestScreenshot: unexpected colour of an unlit pixel. Expected: %v Received: %v", black, received)
+	}
+
+	// High resolution screens keep every pixel
+	chip8.cpu.HiRes = true
+	chip8.cpu.HiGFX[63][127] = 1
+
+	if err := chip8.Screenshot(path, 1); err != nil {
+		t.Fatalf("TestScreenshot: failed to save in high resolution: %v", err)
+	}
+
+	file, err = os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if img, err = png.Decode(file); err != nil {
+		t.Fatalf("TestScreenshot: failed to decode in high resolution: %v", err)
+	}
+
+	if bounds := img.Bounds(); bounds.Dx() != 128 || bounds.Dy() != 64 || color.RGBAModel.Convert(img.At(127, 63)) != white {
+		t.Errorf("TestScreenshot: unexpected high resolution image. Size: %dx%d", bounds.Dx(), bounds.Dy())
+	}
+}
@@ -0,0 +1,58 @@
+package CHIP8
+
+// Instruction 00Cn: Scroll the screen down n pixels.
+func (cpu *CPU) scrollDown(n byte) {
+	cpu.traceln("Instruction 00Cn: Scroll the screen down n pixels.")
+
+	cpu.scroll(0, int(n))
+	cpu.PC += 2
+}
+
+// Instruction 00FB: Scroll the screen right 4 pixels.
+func (cpu *CPU) scrollRight() {
+	cpu.traceln("Instruction 00FB: Scroll the screen right 4 pixels.")
+
+	cpu.scroll(4, 0)
+	cpu.PC += 2
+}
+
+// Instruction 00FC: Scroll the screen left 4 pixels.
+func (cpu *CPU) scrollLeft() {
+	cpu.traceln("Instruction 00FC: Scroll the screen left 4 pixels.")
+
+	cpu.scroll(-4, 0)
+	cpu.PC += 2
+}
+
+// scroll moves the screen in use dx pixels right and dy pixels down. Pixels moved off the edge
+// are lost, and the ones uncovered are cleared. Distances are in pixels of the screen in use,
+// so the same scroll moves half as far across the window in high resolution.
+func (cpu *CPU) scroll(dx int, dy int) {
+	width, height := cpu.screenSize()
+	w, h := int(width), int(height)
+
+	// Walk against the direction of travel, so every pixel is read before it's overwritten
+	for i := 0; i < h; i++ {
+		y := i
+		if dy > 0 {
+			y = h - 1 - i
+		}
+
+		for j := 0; j < w; j++ {
+			x := j
+			if dx > 0 {
+				x = w - 1 - j
+			}
+
+			var value byte
+			if fromX, fromY := x-dx, y-dy; fromX >= 0 && fromX < w && fromY >= 0 && fromY < h {
+				value = *cpu.pixel(uint(fromX), uint(fromY))
+			}
+
+			*cpu.pixel(uint(x), uint(y)) = value
+		}
+	}
+
+	cpu.DF = true
+	cpu.dirty.markAll()
+}
@@ -0,0 +1,53 @@
+package CHIP8
+
+import (
+	"testing"
+)
+
+func TestScroll(t *testing.T) {
+	tests := []struct {
+		name   string
+		opCode uint16
+		hiRes  bool
+		from   [2]int // Marker pixel before the scroll
+		to     [2]int // Where it should end up
+		gone   bool   // Whether it should have scrolled off the screen
+	}{
+		{"down", 0x00C3, false, [2]int{10, 5}, [2]int{10, 8}, false},
+		{"right", 0x00FB, false, [2]int{10, 5}, [2]int{14, 5}, false},
+		{"left", 0x00FC, false, [2]int{10, 5}, [2]int{6, 5}, false},
+		{"down off the bottom", 0x00C2, false, [2]int{10, 30}, [2]int{}, true},
+		{"left off the edge", 0x00FC, false, [2]int{3, 5}, [2]int{}, true},
+		{"hi-res down", 0x00CF, true, [2]int{100, 40}, [2]int{100, 55}, false},
+		{"hi-res right", 0x00FB, true, [2]int{120, 60}, [2]int{124, 60}, false},
+		{"hi-res left", 0x00FC, true, [2]int{70, 33}, [2]int{66, 33}, false},
+	}
+
+	for _, test := range tests {
+		cpu := &CPU{}
+		cpu.HiRes = test.hiRes
+		cpu.PC = 0x200
+		*cpu.pixel(uint(test.from[0]), uint(test.from[1])) = 1
+
+		if err := cpu.execute(test.opCode); err != nil {
+			t.Fatalf("TestScroll: %s failed: %v", test.name, err)
+		}
+
+		if cpu.PC != 0x202 || !cpu.DF {
+			t.Errorf("TestScroll: %s didn't advance PC and set the draw flag. PC: %d", test.name, cpu.PC)
+		}
+
+		// Exactly the marker is lit, wherever it moved to
+		var expectedLo [32][64]byte
+		var expectedHi [64][128]byte
+		if !test.gone && test.hiRes {
+			expectedHi[test.to[1]][test.to[0]] = 1
+		} else if !test.gone {
+			expectedLo[test.to[1]][test.to[0]] = 1
+		}
+
+		if cpu.GFX != expectedLo || cpu.HiGFX != expectedHi {
+			t.Errorf("TestScroll: %s didn't move the pixel at (%d, %d) to (%d, %d)", test.name, test.from[0], test.from[1], test.to[0], test.to[1])
+		}
+	}
+}
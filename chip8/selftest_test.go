@@ -0,0 +1,45 @@
+package CHIP8
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	var out bytes.Buffer
+	if !SelfTest(&out) {
+		t.Errorf("TestSelfTest: self-test failed:\n%s", out.String())
+	}
+
+	for _, test := range selfTests {
+		if !strings.Contains(out.String(), "PASS "+test.name+"\n") {
+			t.Errorf("TestSelfTest: missing PASS for %s. Received: %q", test.name, out.String())
+		}
+	}
+
+	// A wrong golden frame fails
+	if err := runSelfTest("flags", 1); err == nil || !strings.Contains(err.Error(), "frame mismatch") {
+		t.Errorf("TestSelfTest: expected a frame mismatch after 1 cycle. Received: %v", err)
+	}
+}
+
+func TestEncodeGFX(t *testing.T) {
+	var gfx [32][64]byte
+	gfx[0][0] = 1
+	gfx[31][63] = 1
+	gfx[15][32] = 1
+
+	decoded, err := decodeGFX(encodeGFX(&gfx))
+	if err != nil {
+		t.Fatalf("TestEncodeGFX: failed to decode: %v", err)
+	}
+
+	if decoded != gfx {
+		t.Errorf("TestEncodeGFX: round trip changed the frame.\nExpected:\n%s\nReceived:\n%s", gfxString(&gfx), gfxString(&decoded))
+	}
+
+	if _, err := decodeGFX("00"); err == nil {
+		t.Errorf("TestEncodeGFX: expected an error for a short frame")
+	}
+}
@@ -0,0 +1,59 @@
+package CHIP8
+
+// Snapshot is a copy of the machine's observable state at the end of a frame, for debuggers
+// and UIs reading it from another goroutine while the emulator runs.
+type Snapshot struct {
+	GFX   [32][64]byte
+	HiGFX [64][128]byte // SUPER-CHIP high resolution screen, shown instead of GFX if HiRes is set
+	HiRes bool
+	V     [16]byte
+	Stack [16]uint16
+
+	PC uint16
+	SP uint16
+	I  uint16
+
+	DT byte
+	ST byte
+
+	Frame  uint64 // Frame the snapshot was taken at, counting from 0
+	Cycles uint64 // Instructions executed when the snapshot was taken
+}
+
+// snapshot copies the CPU state. Frame is left for the caller to fill in.
+func (cpu *CPU) snapshot() Snapshot {
+	return Snapshot{
+		GFX:    cpu.GFX,
+		HiGFX:  cpu.HiGFX,
+		HiRes:  cpu.HiRes,
+		V:      cpu.V,
+		Stack:  cpu.Stack,
+		PC:     cpu.PC,
+		SP:     cpu.SP,
+		I:      cpu.I,
+		DT:     cpu.DT,
+		ST:     cpu.ST,
+		Cycles: cpu.CycleCount(),
+	}
+}
+
+// takeSnapshot copies the CPU state for Snapshot. It is called once per frame, so readers
+// never touch the CPU while it runs.
+func (chip8 *Chip8) takeSnapshot() {
+	snapshot := chip8.cpu.snapshot()
+	snapshot.Frame = chip8.frame
+
+	chip8.snapshotLock.Lock()
+	defer chip8.snapshotLock.Unlock()
+
+	chip8.snapshot = snapshot
+}
+
+// Snapshot returns a consistent copy of the state at the end of the last frame. It is safe to
+// call from any goroutine while Run is running.
+func (chip8 *Chip8) Snapshot() Snapshot {
+	chip8.snapshotLock.RLock()
+	defer chip8.snapshotLock.RUnlock()
+
+	return chip8.snapshot
+}
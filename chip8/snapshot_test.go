@@ -0,0 +1,73 @@
+package CHIP8
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	chip8 := newTestChip8(&fakeDisplay{})
+	chip8.runFrame(5)
+
+	snapshot := chip8.Snapshot()
+	if expected := uint16(0x200 + 5*2); snapshot.PC != expected {
+		t.Errorf("TestSnapshot: unexpected PC. Expected: %d Received: %d", expected, snapshot.PC)
+	}
+
+	if snapshot.Cycles != 5 {
+		t.Errorf("TestSnapshot: unexpected cycles. Expected: %d Received: %d", 5, snapshot.Cycles)
+	}
+
+	// Snapshots are copies, so later frames don't change them
+	chip8.runFrame(5)
+
+	if expected := uint16(0x200 + 5*2); snapshot.PC != expected {
+		t.Errorf("TestSnapshot: snapshot changed by a later frame. Expected PC: %d Received: %d", expected, snapshot.PC)
+	}
+}
+
+// Run with -race to check readers on other goroutines don't race the run loop.
+func TestSnapshotConcurrentReader(t *testing.T) {
+	chip8 := newTestChip8(&fakeDisplay{})
+	chip8.SetCycleLimit(1000)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		var last uint64
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			snapshot := chip8.Snapshot()
+			if snapshot.Cycles < last {
+				t.Errorf("TestSnapshotConcurrentReader: cycles went backwards. Last: %d Received: %d", last, snapshot.Cycles)
+				return
+			}
+			last = snapshot.Cycles
+
+			// Let the run loop's ticker fire on single CPU machines
+			runtime.Gosched()
+		}
+	}()
+
+	if err := chip8.RunContext(context.Background(), 1000, 11); err != nil {
+		t.Errorf("TestSnapshotConcurrentReader: unexpected error: %v", err)
+	}
+
+	close(done)
+	wg.Wait()
+
+	if snapshot := chip8.Snapshot(); snapshot.Cycles != 1000 {
+		t.Errorf("TestSnapshotConcurrentReader: unexpected cycles. Expected: %d Received: %d", 1000, snapshot.Cycles)
+	}
+}
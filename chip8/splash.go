@@ -0,0 +1,57 @@
+package CHIP8
+
+import "time"
+
+// The "CHIP-8" logo shown before a ROM runs, as 4x5 sprites in the style of the font.
+var splashLogo = [...][5]byte{
+	{0xF0, 0x80, 0x80, 0x80, 0xF0}, // C
+	{0x90, 0x90, 0xF0, 0x90, 0x90}, // H
+	{0xE0, 0x40, 0x40, 0x40, 0xE0}, // I
+	{0xF0, 0x90, 0xF0, 0x80, 0x80}, // P
+	{0x00, 0x00, 0xF0, 0x00, 0x00}, // -
+	{0xF0, 0x90, 0xF0, 0x90, 0xF0}, // 8
+}
+
+// Top left corner of the logo, centring it on the screen.
+const (
+	splashX = (64 - len(splashLogo)*5 + 1) / 2
+	splashY = (32 - 5) / 2
+)
+
+// drawSplash draws the logo into gfx.
+func drawSplash(gfx *[32][64]byte) {
+	for n, glyph := range splashLogo {
+		for i, row := range glyph {
+			for j := uint(0); j < 4; j++ {
+				if row&(0x80>>j) != 0 {
+					gfx[splashY+i][splashX+n*5+int(j)] = 1
+				}
+			}
+		}
+	}
+}
+
+// ShowSplash presents the logo for duration, or until a key is pressed, and reports whether
+// the window was closed meanwhile. The CPU's screen and keys are left as they were.
+func (chip8 *Chip8) ShowSplash(duration time.Duration) bool {
+	var gfx [32][64]byte
+	drawSplash(&gfx)
+	chip8.ppu.Draw(&gfx)
+
+	var key [16]bool
+	for deadline := time.Now().Add(duration); time.Now().Before(deadline); time.Sleep(timerPeriod) {
+		if exit := chip8.ppu.Poll(&key); exit {
+			return true
+		}
+
+		if key != [16]bool{} {
+			break
+		}
+	}
+
+	// Make sure the ROM's first frame replaces the whole logo
+	chip8.cpu.DF = true
+	chip8.cpu.dirty.markAll()
+
+	return false
+}
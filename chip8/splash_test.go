@@ -0,0 +1,68 @@
+package CHIP8
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDrawSplash(t *testing.T) {
+	var gfx [32][64]byte
+	drawSplash(&gfx)
+
+	rows := strings.Split(gfxString(&gfx), "\n")
+
+	expected := []string{
+		"####.#..#.###..####......####",
+		"#....#..#..#...#..#......#..#",
+		"#....####..#...####.####.####",
+		"#....#..#..#...#.........#..#",
+		"####.#..#.###..#.........####",
+	}
+
+	for i, row := range expected {
+		if received := rows[splashY+i][splashX : splashX+len(row)]; received != row {
+			t.Errorf("TestDrawSplash: unexpected logo row %d.\nExpected: %s\nReceived: %s", i, row, received)
+		}
+	}
+
+	lit := strings.Count(gfxString(&gfx), "#")
+	if lit != strings.Count(strings.Join(expected, ""), "#") {
+		t.Errorf("TestDrawSplash: pixels lit outside the logo. Lit: %d", lit)
+	}
+}
+
+func TestShowSplash(t *testing.T) {
+	// A key press skips the rest of the splash
+	display := &fakeDisplay{poll: func(polls int, key *[16]bool) bool {
+		key[0x5] = polls == 2
+		return false
+	}}
+	chip8 := newTestChip8(display)
+
+	start := time.Now()
+	if exit := chip8.ShowSplash(time.Minute); exit {
+		t.Errorf("TestShowSplash: unexpected exit")
+	}
+
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("TestShowSplash: failed to skip the splash on a key press. Took: %v", elapsed)
+	}
+
+	if display.calls[0] != "draw" || display.polls != 2 {
+		t.Errorf("TestShowSplash: unexpected display calls: %v", display.calls)
+	}
+
+	if chip8.cpu.GFX != ([32][64]byte{}) || chip8.cpu.Key != ([16]bool{}) {
+		t.Errorf("TestShowSplash: the splash leaked into the CPU's screen or keys")
+	}
+
+	// Closing the window during the splash exits
+	chip8 = newTestChip8(&fakeDisplay{poll: func(polls int, key *[16]bool) bool {
+		return true
+	}})
+
+	if exit := chip8.ShowSplash(time.Minute); !exit {
+		t.Errorf("TestShowSplash: failed to exit when the window was closed")
+	}
+}
@@ -0,0 +1,128 @@
+package CHIP8
+
+import (
+	"bytes"
+	"io"
+)
+
+// Frames a key stays pressed after its character arrives. Terminals only send characters, never
+// key releases, so a held key is kept pressed by the terminal's key repeat.
+const terminalKeyHold = 6
+
+// terminalKeypad maps the characters of the keys in DefaultKeymap to the keypad.
+var terminalKeypad = map[byte]byte{
+	'1': 0x1, '2': 0x2, '3': 0x3, '4': 0xC,
+	'q': 0x4, 'w': 0x5, 'e': 0x6, 'r': 0xD,
+	'a': 0x7, 's': 0x8, 'd': 0x9, 'f': 0xE,
+	'z': 0xA, 'x': 0x0, 'c': 0xB, 'v': 0xF,
+}
+
+// TerminalDisplay draws frames as text with ANSI escape codes, so the emulator runs over SSH
+// without a window. Each frame redraws the screen in place rather than scrolling. Keys are read
+// from the terminal, which should be put in non-canonical mode without echo, e.g. with
+// stty -icanon -echo. Ctrl-C quits when the terminal doesn't turn it into a signal.
+type TerminalDisplay struct {
+	out   io.Writer
+	input chan byte
+
+	held    [16]int // Frames left to hold each key down
+	started bool    // Whether the terminal has been cleared
+	buf     bytes.Buffer
+}
+
+// TerminalDisplay can stand in for the PPU in the run loop.
+var _ Display = (*TerminalDisplay)(nil)
+
+// NewTerminalDisplay returns a TerminalDisplay drawing to out and reading keys from in.
+func NewTerminalDisplay(in io.Reader, out io.Writer) *TerminalDisplay {
+	display := &TerminalDisplay{out: out, input: make(chan byte, 64)}
+
+	// Reads block, so they're done on their own goroutine and picked up by Poll
+	go func() {
+		defer close(display.input)
+
+		b := make([]byte, 1)
+		for {
+			if _, err := in.Read(b); err != nil {
+				return
+			}
+			display.input <- b[0]
+		}
+	}()
+
+	return display
+}
+
+func (display *TerminalDisplay) Draw(gfx *[32][64]byte) {
+	display.buf.Reset()
+
+	// Clear the terminal and hide the cursor once, then move it home before each frame
+	if !display.started {
+		display.buf.WriteString("\x1b[2J\x1b[?25l")
+		display.started = true
+	}
+	display.buf.WriteString("\x1b[H")
+
+	for i := range gfx {
+		for j := range gfx[i] {
+			if gfx[i][j] != 0 {
+				display.buf.WriteString("█")
+			} else {
+				display.buf.WriteByte(' ')
+			}
+		}
+		display.buf.WriteString("\r\n")
+	}
+
+	display.out.Write(display.buf.Bytes())
+}
+
+func (display *TerminalDisplay) Poll(key *[16]bool) bool {
+	for i := range display.held {
+		if display.held[i] > 0 {
+			display.held[i]--
+		}
+	}
+
+	for polling := true; polling; {
+		select {
+		case b, ok := <-display.input:
+			if !ok {
+				// Input closed, keep running without it
+				display.input = nil
+				polling = false
+				continue
+			}
+
+			// Ctrl-C
+			if b == 0x03 {
+				return true
+			}
+
+			// Upper case too, in case Caps Lock is on
+			if b >= 'A' && b <= 'Z' {
+				b += 'a' - 'A'
+			}
+
+			if pressed, ok := terminalKeypad[b]; ok {
+				display.held[pressed] = terminalKeyHold
+			}
+
+		default:
+			polling = false
+		}
+	}
+
+	for i := range display.held {
+		key[i] = display.held[i] > 0
+	}
+
+	return false
+}
+
+// Destroy shows the cursor again and leaves it below the last frame.
+func (display *TerminalDisplay) Destroy() {
+	if display.started {
+		io.WriteString(display.out, "\x1b[?25h\r\n")
+	}
+}
@@ -0,0 +1,78 @@
+package CHIP8
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTerminalDisplayDraw(t *testing.T) {
+	var out bytes.Buffer
+	display := NewTerminalDisplay(strings.NewReader(""), &out)
+
+	var gfx [32][64]byte
+	gfx[0][0] = 1
+	gfx[0][2] = 1
+	gfx[31][63] = 1
+
+	display.Draw(&gfx)
+
+	blank := strings.Repeat(" ", 64) + "\r\n"
+	expected := "\x1b[2J\x1b[?25l\x1b[H" +
+		"█ █" + strings.Repeat(" ", 61) + "\r\n" +
+		strings.Repeat(blank, 30) +
+		strings.Repeat(" ", 63) + "█\r\n"
+
+	if out.String() != expected {
+		t.Errorf("TestTerminalDisplayDraw: unexpected first frame. Expected:\n%q\nReceived:\n%q", expected, out.String())
+	}
+
+	// Later frames redraw in place without clearing
+	out.Reset()
+	display.Draw(&[32][64]byte{})
+
+	if expected := "\x1b[H" + strings.Repeat(blank, 32); out.String() != expected {
+		t.Errorf("TestTerminalDisplayDraw: unexpected second frame. Expected:\n%q\nReceived:\n%q", expected, out.String())
+	}
+
+	out.Reset()
+	display.Destroy()
+
+	if out.String() != "\x1b[?25h\r\n" {
+		t.Errorf("TestTerminalDisplayDraw: failed to show the cursor. Received: %q", out.String())
+	}
+}
+
+func TestTerminalDisplayPoll(t *testing.T) {
+	display := NewTerminalDisplay(strings.NewReader("wV"), &bytes.Buffer{})
+
+	var key [16]bool
+	deadline := time.Now().Add(time.Second)
+	for !key[0x5] || !key[0xF] {
+		if time.Now().After(deadline) {
+			t.Fatalf("TestTerminalDisplayPoll: failed to press 5 and F. Received: %v", key)
+		}
+
+		if display.Poll(&key) {
+			t.Fatalf("TestTerminalDisplayPoll: asked to quit")
+		}
+	}
+
+	// Without key releases, keys are let go after a few frames
+	for frame := 0; frame < terminalKeyHold; frame++ {
+		display.Poll(&key)
+	}
+
+	if key != [16]bool{} {
+		t.Errorf("TestTerminalDisplayPoll: failed to release the keys. Received: %v", key)
+	}
+
+	display = NewTerminalDisplay(strings.NewReader("\x03"), &bytes.Buffer{})
+	deadline = time.Now().Add(time.Second)
+	for !display.Poll(&key) {
+		if time.Now().After(deadline) {
+			t.Fatalf("TestTerminalDisplayPoll: failed to quit on Ctrl-C")
+		}
+	}
+}
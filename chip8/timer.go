@@ -0,0 +1,60 @@
+package CHIP8
+
+import "time"
+
+// The delay and sound timers count down at 60Hz, however often frames are presented.
+const timerPeriod = time.Second / 60
+
+// timerClock converts the real time between frames into 60Hz timer ticks, carrying any
+// remainder over so the timers keep the right rate at any frame rate.
+type timerClock struct {
+	elapsed time.Duration // Time accumulated since the last tick
+}
+
+// advance adds d to the clock and returns the number of timer ticks that are now due.
+func (clock *timerClock) advance(d time.Duration) int {
+	clock.elapsed += d
+
+	ticks := int(clock.elapsed / timerPeriod)
+	clock.elapsed -= time.Duration(ticks) * timerPeriod
+
+	return ticks
+}
+
+// presentClock limits how many of the 60 frames emulated each second are presented, so a lower
+// frame rate saves work without slowing emulation down.
+type presentClock struct {
+	fps    int // Frames presented per second, or 0 to present every frame
+	credit int // Accumulates fps per frame, a frame being due once it reaches 60
+}
+
+// advance counts a frame and reports whether it is due to be presented.
+func (clock *presentClock) advance() bool {
+	if clock.fps <= 0 || clock.fps >= 60 {
+		return true
+	}
+
+	if clock.credit < 60 {
+		clock.credit += clock.fps
+	}
+
+	return clock.credit >= 60
+}
+
+// presented starts the wait for the next frame, carrying over any credit past a frame.
+func (clock *presentClock) presented() {
+	if clock.credit >= 60 {
+		clock.credit -= 60
+	}
+}
+
+// tickTimers counts the delay and sound timers down by one.
+func (cpu *CPU) tickTimers() {
+	if cpu.DT > 0 {
+		cpu.DT -= 1
+	}
+
+	if cpu.ST > 0 {
+		cpu.ST -= 1
+	}
+}
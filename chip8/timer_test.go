@@ -0,0 +1,40 @@
+package CHIP8
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimerClock(t *testing.T) {
+	// Presenting at 30, 60, 75 and 144Hz all count the timers down 60 times a second
+	for _, hz := range []int{30, 60, 75, 144} {
+		clock := &timerClock{}
+		cpu := &CPU{}
+		cpu.DT = 200
+		cpu.ST = 200
+
+		for frame := 0; frame < hz; frame++ {
+			for n := clock.advance(time.Second / time.Duration(hz)); n > 0; n-- {
+				cpu.tickTimers()
+			}
+		}
+
+		// Frame times don't divide a second exactly, so the last tick may still be pending
+		if ticks := 200 - int(cpu.DT); ticks < 59 || ticks > 60 {
+			t.Errorf("TestTimerClock: unexpected ticks in a second at %dHz. Expected: %d Received: %d", hz, 60, ticks)
+		}
+
+		if cpu.ST != cpu.DT {
+			t.Errorf("TestTimerClock: sound and delay timers diverged at %dHz. DT: %d ST: %d", hz, cpu.DT, cpu.ST)
+		}
+	}
+
+	// Timers stop at 0
+	cpu := &CPU{}
+	cpu.DT = 1
+	cpu.tickTimers()
+	cpu.tickTimers()
+	if cpu.DT != 0 || cpu.ST != 0 {
+		t.Errorf("TestTimerClock: timers counted below 0. DT: %d ST: %d", cpu.DT, cpu.ST)
+	}
+}
@@ -1,34 +1,346 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"github.com/clint07/CHIP-8/chip8"
+	"os"
+	"os/exec"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
+	"time"
 )
 
 func main() {
 	// Parse command line arguments
-	flagFilename := flag.String("file", "", "ROM filename")
-	flagFps := flag.String("fps", "120", "120 FPS recommended unless using ROMs such as a clock ROM")
+	flagFilename := flag.String("file", "", "ROM filename, or an http(s):// URL to fetch it from")
+	flagBuiltin := flag.String("builtin", "", "Name of a bundled ROM to run instead of --file")
+	flagListBuiltins := flag.Bool("list-builtins", false, "List the bundled ROMs and exit")
+	flagFps := flag.String("fps", "60", "Most frames presented per second. Emulation always runs at 60 frames per second")
+	flagIpf := flag.Int("ipf", 11, "Instructions per frame. The CPU runs at 60 * ipf instructions per second")
+	flagSeed := flag.Int64("seed", 0, "Seed for the random number generator (0 seeds from the clock)")
+	flagRecordInput := flag.String("record-input", "", "Record keypad input to a file for later playback")
+	flagPlayInput := flag.String("play-input", "", "Play back keypad input recorded with --record-input")
+	flagCycles := flag.Uint64("cycles", 0, "Exit after this many instructions (0 runs until the window is closed)")
+	flagFade := flag.Int("fade", 0, "Frames for pixels to fade out, reducing flicker (0 disables)")
+	flagMute := flag.Bool("mute", false, "Silence the beep")
+	flagVolume := flag.Float64("volume", 1.0, "Beep volume from 0.0 to 1.0")
+	flagBeepHz := flag.Float64("beep-hz", 440, "Pitch of the beep in Hz")
+	flagRewind := flag.Int("rewind", 0, "Frames kept for rewinding with Backspace, about 70KB each (0 disables)")
+	flagFlagsFile := flag.String("flags-file", "", "File keeping the SUPER-CHIP user flags (Fx75/Fx85) between runs, e.g. for high scores")
+	flagScale := flag.Int("scale", 10, "Window pixels per CHIP-8 pixel. The window is 64 * scale by 32 * scale")
+	flagFg := flag.String("fg", "FFFFFF", "Colour of lit pixels as RRGGBB, e.g. FFB000 for amber")
+	flagBg := flag.String("bg", "000000", "Background colour as RRGGBB")
+	flagPixelGap := flag.Int("pixel-gap", 0, "Window pixels left between neighbouring pixels for a grid look (0 draws them solid)")
+	flagTimeout := flag.Duration("timeout", 0, "Stop after this much wall-clock time, e.g. 30s (0 runs until the window is closed)")
+	flagVSync := flag.Bool("vsync", false, "Pace frames by the display's refresh rate instead of a 60Hz timer")
+	flagSelfTest := flag.Bool("self-test", false, "Run the bundled conformance ROMs headless, print PASS or FAIL for each and exit")
+	flagROMInfo := flag.Bool("rom-info", false, "Print the ROM's size, platform and SHA-1 without running it")
+	flagDisasm := flag.Bool("disasm", false, "Print the ROM's instructions without running it")
+	flagNoSplash := flag.Bool("no-splash", false, "Skip the logo shown before the ROM runs")
+	flagRecordGIF := flag.String("record-gif", "", "Record the screen to an animated GIF, written on exit")
+	flagRecordGIFFrames := flag.Int("record-gif-frames", 600, "Most screens kept by --record-gif, later ones are dropped (0 keeps all)")
+	flagScreenshot := flag.String("screenshot", "", "Save the final screen as a PNG on exit")
+	flagDumpGFX := flag.Bool("dump-gfx", false, "Print the final screen as text on exit")
+	flagRender := flag.String("render", "sdl", "Renderer: sdl opens a window, terminal draws in the terminal, none runs headless without SDL (use with --cycles)")
+	flagPCCheck := flag.String("pc-check", "lenient", "What to do when PC lands on an odd address: lenient, warn or strict")
+	flagLogLevel := flag.String("log-level", "quiet", "How much to log: quiet, info (detected ROMs) or trace (every instruction)")
+	flagLogFile := flag.String("log-file", "", "Write the instruction trace and other messages to this file instead of the console")
+	flagLogMode := flag.String("log-mode", "truncate", "What to do with an existing --log-file: truncate, append or rotate (keeps it as FILE.1)")
+	flagQuirks := flag.String("quirks", "", "Quirks profile of the platform to emulate: chip8, schip or xochip")
+	flagQuirkShift := flag.Bool("quirk-shift", false, "8xy6/8xyE shift Vy into Vx instead of shifting Vx")
+	flagQuirkLoadStore := flag.Bool("quirk-load-store", false, "Fx55/Fx65 increment I")
+	flagQuirkJump := flag.Bool("quirk-jump", false, "Bnnn jumps to xnn + Vx instead of nnn + V0")
+	flagQuirkVFReset := flag.Bool("quirk-vf-reset", false, "8xy1/8xy2/8xy3 reset VF to 0")
+	flagQuirkClip := flag.Bool("quirk-clip", false, "Clip sprites at the screen edges instead of wrapping")
+	flagQuirkDisplayWait := flag.Bool("quirk-display-wait", false, "Dxyn waits for the next frame, drawing at most one sprite per frame")
+	flagQuirkExtendedMemory := flag.Bool("quirk-extended-memory", false, "Address 64KB of RAM like XO-CHIP instead of 4KB")
 	flag.Parse()
 
-	// Initialize CHIP-8
+	if *flagListBuiltins {
+		for _, name := range CHIP8.Builtins() {
+			fmt.Println(name)
+		}
+		return
+	}
+
+	// Check the build against the conformance ROMs
+	if *flagSelfTest {
+		if !CHIP8.SelfTest(os.Stdout) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Inspect the ROM instead of running it
+	if *flagROMInfo {
+		rom, err := CHIP8.ReadROM(*flagFilename)
+		if err != nil {
+			panic(err)
+		}
+
+		CHIP8.InspectROM(rom).Print(os.Stdout)
+		return
+	}
+
+	// List the ROM's instructions instead of running it
+	if *flagDisasm {
+		rom, err := CHIP8.ReadROM(*flagFilename)
+		if err != nil {
+			panic(err)
+		}
+
+		for _, line := range CHIP8.Disassemble(rom, 0x200) {
+			fmt.Println(line)
+		}
+		return
+	}
+
+	// Check the frame rate before opening a window
+	fps, err := strconv.Atoi(*flagFps)
+	if err != nil {
+		panic(err)
+	}
+
+	if fps <= 0 {
+		panic(fmt.Sprintf("--fps must be at least 1, got %d", fps))
+	}
+
+	// Exit with exitCode once everything deferred below has run, e.g. flushing the log
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
+	// Initialize CHIP-8, with or without a window
 	chip8 := CHIP8.Chip8{}
-	chip8.Init()
+	headless := false
+
+	switch *flagRender {
+	case "sdl":
+		if err := chip8.Init(); err != nil {
+			panic(err)
+		}
+	case "none":
+		chip8.InitHeadless()
+		headless = true
+	case "terminal":
+		chip8.InitHeadless()
+		headless = true
+
+		// Read keys as they're typed, without echoing them over the screen
+		stty("-icanon", "-echo", "min", "1")
+		defer stty("sane")
+
+		chip8.SetDisplay(CHIP8.NewTerminalDisplay(os.Stdin, os.Stdout))
+	default:
+		panic(fmt.Sprintf("unknown renderer %q", *flagRender))
+	}
+
+	// Send the trace to a log file, flushed once the emulator stops
+	if *flagLogFile != "" {
+		file, err := CHIP8.OpenLog(*flagLogFile, *flagLogMode)
+		if err != nil {
+			panic(err)
+		}
+		defer file.Close()
+
+		log := bufio.NewWriter(file)
+		defer log.Flush()
+
+		chip8.SetLog(log)
+	}
+
+	logLevel, err := CHIP8.ParseLogLevel(*flagLogLevel)
+	if err != nil {
+		panic(err)
+	}
+	chip8.SetLogLevel(logLevel)
+
+	// Pick a quirks profile, then let individual quirk flags override it. They're set before
+	// loading, so 64KB XO-CHIP ROMs fit, and so known ROMs only get detected quirks without them.
+	var quirks CHIP8.Quirks
+	quirksSet := *flagQuirks != ""
+	if *flagQuirks != "" {
+		var err error
+		if quirks, err = CHIP8.QuirksProfile(*flagQuirks); err != nil {
+			panic(err)
+		}
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		if strings.HasPrefix(f.Name, "quirk-") {
+			quirksSet = true
+		}
+
+		switch f.Name {
+		case "quirk-shift":
+			quirks.ShiftUsesVY = *flagQuirkShift
+		case "quirk-load-store":
+			quirks.LoadStoreIncrementsI = *flagQuirkLoadStore
+		case "quirk-jump":
+			quirks.JumpUsesVX = *flagQuirkJump
+		case "quirk-vf-reset":
+			quirks.LogicResetsVF = *flagQuirkVFReset
+		case "quirk-clip":
+			quirks.ClipSprites = *flagQuirkClip
+		case "quirk-display-wait":
+			quirks.DisplayWait = *flagQuirkDisplayWait
+		case "quirk-extended-memory":
+			quirks.ExtendedMemory = *flagQuirkExtendedMemory
+		}
+	})
+
+	if quirksSet {
+		chip8.SetQuirks(quirks)
+	}
 
 	// Load ROM
-	if err := chip8.Load(flagFilename); err != nil {
+	if *flagBuiltin != "" {
+		if err := chip8.LoadBuiltin(*flagBuiltin); err != nil {
+			panic(err)
+		}
+	} else if err := chip8.Load(flagFilename); err != nil {
 		panic(err)
 	}
 
-	// Run ROM
-	fps, err := strconv.Atoi(*flagFps)
+	pcCheck, err := CHIP8.ParsePCCheck(*flagPCCheck)
 	if err != nil {
 		panic(err)
 	}
+	chip8.SetPCCheck(pcCheck)
+
+	if *flagSeed != 0 {
+		chip8.Seed(*flagSeed)
+	}
 
-	chip8.Run(fps)
+	chip8.SetCycleLimit(*flagCycles)
+	chip8.SetFade(*flagFade)
+	chip8.SetScale(*flagScale)
+	chip8.SetPixelGap(*flagPixelGap)
+
+	fg, err := CHIP8.ParseColor(*flagFg)
+	if err != nil {
+		panic(err)
+	}
+
+	bg, err := CHIP8.ParseColor(*flagBg)
+	if err != nil {
+		panic(err)
+	}
+
+	chip8.SetColors(fg, bg)
+
+	chip8.SetRewind(*flagRewind)
+
+	if err := chip8.SetVSync(*flagVSync); err != nil {
+		panic(err)
+	}
+	chip8.SetMuted(*flagMute)
+	chip8.SetVolume(*flagVolume)
+	chip8.SetBeepFrequency(*flagBeepHz)
+
+	// Record or replay input
+	if *flagRecordInput != "" {
+		file, err := os.Create(*flagRecordInput)
+		if err != nil {
+			panic(err)
+		}
+		defer file.Close()
+
+		chip8.RecordInput(file)
+	}
+
+	if *flagPlayInput != "" {
+		file, err := os.Open(*flagPlayInput)
+		if err != nil {
+			panic(err)
+		}
+		defer file.Close()
+
+		if err := chip8.PlayInput(file); err != nil {
+			panic(err)
+		}
+	}
+
+	// Restore the SUPER-CHIP user flags from the last run
+	if *flagFlagsFile != "" {
+		if err := chip8.LoadFlags(*flagFlagsFile); err != nil {
+			panic(err)
+		}
+	}
+
+	if *flagRecordGIF != "" {
+		if err := chip8.StartRecording(*flagRecordGIF, *flagRecordGIFFrames, 0); err != nil {
+			panic(err)
+		}
+	}
+
+	// Stop on Ctrl-C or SIGTERM so SDL still gets shut down
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Give up on ROMs that run too long, e.g. in a test harness
+	if *flagTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *flagTimeout)
+		defer cancel()
+	}
+
+	// Run ROM, showing the logo for a second first, skippable with any key
+	var runErr error
+	if *flagNoSplash || headless || !chip8.ShowSplash(time.Second) {
+		runErr = chip8.RunContext(ctx, fps, *flagIpf)
+	}
+
+	// Being interrupted or timing out is a normal way to stop, but a failing ROM isn't
+	failed := runErr != nil && !errors.Is(runErr, context.Canceled) && !errors.Is(runErr, context.DeadlineExceeded)
+	if failed {
+		fmt.Fprintf(os.Stderr, "\nCHIP-8 stopped: %v\n", runErr)
+		chip8.DumpState(os.Stderr)
+	}
+
+	if *flagFlagsFile != "" {
+		if err := chip8.SaveFlags(*flagFlagsFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+
+	if *flagRecordGIF != "" {
+		if err := chip8.StopRecording(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+
+	if *flagScreenshot != "" {
+		if err := chip8.Screenshot(*flagScreenshot, *flagScale); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+
+	if *flagDumpGFX {
+		chip8.DumpGFX(os.Stdout)
+	}
 
 	// Shutdown CHIP-8
 	chip8.Shutdown()
+
+	if failed {
+		exitCode = 1
+	}
+}
+
+// stty changes the settings of the terminal on stdin. It's best effort: without a terminal, keys
+// are only read once a line is entered.
+func stty(args ...string) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Run()
 }